@@ -0,0 +1,173 @@
+package dynamic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	basev1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/v1beta1"
+	"github.com/fdymylja/dynamic-cosmos/proof"
+	tmcrypto "github.com/tendermint/tendermint/proto/tendermint/crypto"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	"google.golang.org/protobuf/proto"
+)
+
+// bankBalancesPrefix is the bank store prefix of the account balances.
+const bankBalancesPrefix = 0x02
+
+// AppHashProvider provides the app hashes the store proofs are verified against.
+type AppHashProvider interface {
+	// AppHash returns the app hash committing to the state at height,
+	// which is the one of the header at height+1.
+	AppHash(ctx context.Context, height int64) ([]byte, error)
+}
+
+// ErrNoAppHashProvider is returned by Client.QueryStore when no trusted source of app hashes
+// was set using WithAppHashProvider or WithLightClient.
+var ErrNoAppHashProvider = errors.New("no app hash provider set, store proofs cannot be verified")
+
+// WithAppHashProvider sets the AppHashProvider used by Client.QueryStore to verify proofs.
+// The provider must be trusted, as proofs only prove the values are committed by its app hashes.
+func WithAppHashProvider(provider AppHashProvider) DialOption {
+	return func(options *options) {
+		options.appHashes = provider
+	}
+}
+
+// ABCIQuery is like DynamicQuery but the query is sent through the tendermint RPC abci_query,
+// see QueryJSON for the accepted method path formats. The response is not verified, as nodes
+// do not provide proofs for gRPC queries, see QueryStore.
+func (c *Client) ABCIQuery(ctx context.Context, method string, req, resp proto.Message, opts ...QueryOption) error {
+	service, name, ok := splitMethodPath(method)
+	if !ok {
+		return fmt.Errorf("invalid method path %q", method)
+	}
+	data, err := c.Codec.MarshalProto(req)
+	if err != nil {
+		return err
+	}
+
+	o := newQueryOptions(opts)
+	value, _, err := c.abciQuery(ctx, fmt.Sprintf("/%s/%s", service, name), data, o, false)
+	if err != nil {
+		return err
+	}
+	return c.Codec.UnmarshalProto(value, resp)
+}
+
+// QueryStore returns the value of key in the module store named store, or nil if the key
+// is absent, verifying its ICS-23 proof against the app hash provided by the AppHashProvider,
+// see WithAppHashProvider and WithLightClient, otherwise ErrNoAppHashProvider is returned.
+// Without AtHeight the state at the latest height minus one is queried, as the app hash
+// committing to the latest state is not yet available in a header.
+func (c *Client) QueryStore(ctx context.Context, store string, key []byte, opts ...QueryOption) ([]byte, error) {
+	if c.appHashes == nil {
+		return nil, ErrNoAppHashProvider
+	}
+	tm, err := c.Tendermint()
+	if err != nil {
+		return nil, err
+	}
+
+	o := newQueryOptions(opts)
+	if o.height == 0 {
+		status, err := tm.Status(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch latest height: %w", err)
+		}
+		o.height = status.SyncInfo.LatestBlockHeight - 1
+	}
+
+	value, res, err := c.abciQuery(ctx, fmt.Sprintf("/store/%s/key", store), key, o, true)
+	if err != nil {
+		return nil, err
+	}
+
+	appHash, err := c.appHashes.AppHash(ctx, res.Height)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch app hash at height %d: %w", res.Height, err)
+	}
+	if len(value) == 0 {
+		value = nil
+	}
+	err = proof.VerifyStoreValue(res.ProofOps, appHash, store, key, value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// VerifiedBalance returns the balance of the account in the denom, verified using QueryStore.
+func (c *Client) VerifiedBalance(ctx context.Context, address string, denom string, opts ...QueryOption) (*basev1beta1.Coin, error) {
+	addr, err := c.Addresses.Decode(address)
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 0, 2+len(addr)+len(denom))
+	key = append(key, bankBalancesPrefix, byte(len(addr)))
+	key = append(key, addr...)
+	key = append(key, denom...)
+
+	value, err := c.QueryStore(ctx, "bank", key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBalance(value, denom)
+}
+
+// decodeBalance decodes a bank store balance, which is a Coin up to
+// cosmos-sdk v0.45 and the amount as decimal string from v0.46.
+func decodeBalance(value []byte, denom string) (*basev1beta1.Coin, error) {
+	if value == nil {
+		return &basev1beta1.Coin{Denom: denom, Amount: "0"}, nil
+	}
+	if strings.Trim(string(value), "0123456789") == "" {
+		return &basev1beta1.Coin{Denom: denom, Amount: string(value)}, nil
+	}
+	coin := new(basev1beta1.Coin)
+	err := proto.Unmarshal(value, coin)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode balance: %w", err)
+	}
+	if coin.Denom != denom {
+		return nil, fmt.Errorf("balance denom %s does not match %s", coin.Denom, denom)
+	}
+	return coin, nil
+}
+
+// abciQueryResult is the part of the abci_query response used to verify proofs.
+type abciQueryResult struct {
+	Height   int64
+	ProofOps *tmcrypto.ProofOps
+}
+
+func (c *Client) abciQuery(ctx context.Context, path string, data []byte, o *queryOptions, prove bool) ([]byte, *abciQueryResult, error) {
+	if o.height < 0 {
+		return nil, nil, fmt.Errorf("invalid height %d", o.height)
+	}
+	tm, err := c.Tendermint()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := tm.ABCIQueryWithOptions(ctx, path, data, rpcclient.ABCIQueryOptions{Height: o.height, Prove: prove})
+	if err != nil {
+		return nil, nil, err
+	}
+	resp := res.Response
+	if !resp.IsOK() {
+		err = fmt.Errorf("abci query %s failed with code %d (codespace %s): %s", path, resp.Code, resp.Codespace, resp.Log)
+		if o.height > 0 && isHeightUnavailable(err) {
+			return nil, nil, &HeightUnavailableError{Height: o.height, Err: err}
+		}
+		return nil, nil, err
+	}
+	if o.height > 0 && resp.Height != o.height {
+		return nil, nil, fmt.Errorf("requested height %d but the node served height %d", o.height, resp.Height)
+	}
+	if o.servedHeight != nil {
+		*o.servedHeight = resp.Height
+	}
+	return resp.Value, &abciQueryResult{Height: resp.Height, ProofOps: resp.ProofOps}, nil
+}
@@ -0,0 +1,29 @@
+package dynamic
+
+import (
+	"testing"
+
+	basev1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/v1beta1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDecodeBalance(t *testing.T) {
+	// cosmos-sdk v0.46 and later
+	coin, err := decodeBalance([]byte("1000"), "stake")
+	require.NoError(t, err)
+	require.Equal(t, "1000", coin.Amount)
+
+	// up to cosmos-sdk v0.45
+	b, err := proto.Marshal(&basev1beta1.Coin{Denom: "stake", Amount: "5"})
+	require.NoError(t, err)
+	coin, err = decodeBalance(b, "stake")
+	require.NoError(t, err)
+	require.Equal(t, "5", coin.Amount)
+	_, err = decodeBalance(b, "atom")
+	require.ErrorContains(t, err, "does not match")
+
+	coin, err = decodeBalance(nil, "stake")
+	require.NoError(t, err)
+	require.Equal(t, "0", coin.Amount)
+}
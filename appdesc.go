@@ -0,0 +1,177 @@
+package dynamic
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	authv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/auth/v1beta1"
+	reflectionv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/reflection/v1beta1"
+	reflectionv2alpha1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/reflection/v2alpha1"
+	tendermintv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+	"github.com/fdymylja/dynamic-cosmos/codec"
+	"github.com/fdymylja/dynamic-cosmos/protoutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// msgInterfaceName is the name of the sdk.Msg interface in the v1beta1 reflection service.
+const msgInterfaceName = "cosmos.base.v1beta1.Msg"
+
+// WithBech32Prefix sets the bech32 account address prefix used when the chain
+// does not expose the cosmos.base.reflection.v2alpha1 reflection service.
+func WithBech32Prefix(prefix string) DialOption {
+	return func(options *options) {
+		options.bech32Prefix = prefix
+	}
+}
+
+// fallbackAppDesc reconstructs the app descriptor for chains which do not expose
+// the cosmos.base.reflection.v2alpha1 reflection service, using the v1beta1 reflection
+// service, gRPC reflection, the tendermint service and the auth module.
+func (o *options) fallbackAppDesc(ctx context.Context, conn grpc.ClientConnInterface, registry *codec.Registry) error {
+	codecDesc, msgs, err := v1beta1Interfaces(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("unable to fetch interfaces using v1beta1 reflection: %w", err)
+	}
+
+	queries, err := queryServices(ctx, conn, registry)
+	if err != nil {
+		return fmt.Errorf("unable to fetch query services using gRPC reflection: %w", err)
+	}
+
+	nodeInfo, err := tendermintv1beta1.NewServiceClient(conn).GetNodeInfo(ctx, &tendermintv1beta1.GetNodeInfoRequest{})
+	if err != nil {
+		return fmt.Errorf("unable to fetch chain ID: %w", err)
+	}
+
+	prefix := o.bech32Prefix
+	if prefix == "" {
+		resp, err := authv1beta1.NewQueryClient(conn).Bech32Prefix(ctx, &authv1beta1.Bech32PrefixRequest{})
+		if err != nil {
+			return fmt.Errorf("unable to fetch bech32 prefix, it can be set using WithBech32Prefix: %w", err)
+		}
+		prefix = resp.Bech32Prefix
+	}
+
+	o.appDesc = &reflectionv2alpha1.AppDescriptor{
+		Authn: &reflectionv2alpha1.AuthnDescriptor{
+			SignModes: []*reflectionv2alpha1.SigningModeDescriptor{{Name: "SIGN_MODE_DIRECT", Number: 1}},
+		},
+		Chain:         &reflectionv2alpha1.ChainDescriptor{Id: nodeInfo.GetNodeInfo().GetNetwork()},
+		Codec:         codecDesc,
+		Configuration: &reflectionv2alpha1.ConfigurationDescriptor{Bech32AccountAddressPrefix: prefix},
+		QueryServices: queries,
+		Tx: &reflectionv2alpha1.TxDescriptor{
+			Fullname: "cosmos.tx.v1beta1.Tx",
+			Msgs:     msgs,
+		},
+	}
+	return nil
+}
+
+func v1beta1Interfaces(ctx context.Context, conn grpc.ClientConnInterface) (*reflectionv2alpha1.CodecDescriptor, []*reflectionv2alpha1.MsgDescriptor, error) {
+	rc := reflectionv1beta1.NewReflectionServiceClient(conn)
+	interfaces, err := rc.ListAllInterfaces(ctx, &reflectionv1beta1.ListAllInterfacesRequest{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	codecDesc := &reflectionv2alpha1.CodecDescriptor{}
+	var msgs []*reflectionv2alpha1.MsgDescriptor
+	for _, name := range interfaces.InterfaceNames {
+		impls, err := rc.ListImplementations(ctx, &reflectionv1beta1.ListImplementationsRequest{InterfaceName: name})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		desc := &reflectionv2alpha1.InterfaceDescriptor{Fullname: name}
+		for _, typeURL := range impls.ImplementationMessageNames {
+			desc.InterfaceImplementers = append(desc.InterfaceImplementers, &reflectionv2alpha1.InterfaceImplementerDescriptor{
+				Fullname: string(protoutil.FullNameFromURL(typeURL)),
+				TypeUrl:  typeURL,
+			})
+			if name == msgInterfaceName {
+				msgs = append(msgs, &reflectionv2alpha1.MsgDescriptor{MsgTypeUrl: typeURL})
+			}
+		}
+		codecDesc.Interfaces = append(codecDesc.Interfaces, desc)
+	}
+
+	return codecDesc, msgs, nil
+}
+
+// nonModuleQueryServices are the query services which are not provided by modules.
+var nonModuleQueryServices = map[string]struct{}{
+	"cosmos.base.reflection.v1beta1.ReflectionService": {},
+	"cosmos.base.tendermint.v1beta1.Service":           {},
+}
+
+// queryServices lists the query services exposed by the node using gRPC reflection: the module
+// Query services and the nonModuleQueryServices, other services like cosmos.tx.v1beta1.Service
+// are not queries.
+func queryServices(ctx context.Context, conn grpc.ClientConnInterface, registry *codec.Registry) (*reflectionv2alpha1.QueryServicesDescriptor, error) {
+	stream, err := grpc_reflection_v1alpha.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+
+	err = stream.Send(&grpc_reflection_v1alpha.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1alpha.ServerReflectionRequest_ListServices{ListServices: "*"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	list := resp.GetListServicesResponse()
+	if list == nil {
+		return nil, fmt.Errorf("unexpected reflection response: %v", resp.GetErrorResponse())
+	}
+
+	names := make([]string, 0, len(list.Service))
+	for _, svc := range list.Service {
+		if _, ok := nonModuleQueryServices[svc.Name]; !ok && !strings.HasSuffix(svc.Name, ".Query") {
+			continue
+		}
+		names = append(names, svc.Name)
+	}
+	sort.Strings(names)
+
+	queries := &reflectionv2alpha1.QueryServicesDescriptor{}
+	for _, name := range names {
+		desc, err := registry.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve service %s: %w", name, err)
+		}
+		sd, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a service", name)
+		}
+
+		svc := &reflectionv2alpha1.QueryServiceDescriptor{
+			Fullname: name,
+			IsModule: sd.Name() == "Query",
+		}
+		for i := 0; i < sd.Methods().Len(); i++ {
+			md := sd.Methods().Get(i)
+			svc.Methods = append(svc.Methods, &reflectionv2alpha1.QueryMethodDescriptor{
+				Name:          string(md.Name()),
+				FullQueryPath: fmt.Sprintf("/%s/%s", name, md.Name()),
+			})
+		}
+		queries.QueryServices = append(queries.QueryServices, svc)
+	}
+	return queries, nil
+}
+
+func isUnimplemented(err error) bool {
+	return status.Code(err) == codes.Unimplemented
+}
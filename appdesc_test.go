@@ -0,0 +1,66 @@
+package dynamic
+
+import (
+	"context"
+	"testing"
+
+	bankv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/bank/v1beta1"
+	reflectionv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/reflection/v1beta1"
+	tendermintv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+	txv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/tx/v1beta1"
+	"github.com/cosmos/cosmos-sdk/api/tendermint/p2p"
+	"github.com/fdymylja/dynamic-cosmos/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// legacyReflection is a v1beta1 reflection service exposing a single message.
+type legacyReflection struct {
+	reflectionv1beta1.UnimplementedReflectionServiceServer
+}
+
+func (legacyReflection) ListAllInterfaces(context.Context, *reflectionv1beta1.ListAllInterfacesRequest) (*reflectionv1beta1.ListAllInterfacesResponse, error) {
+	return &reflectionv1beta1.ListAllInterfacesResponse{InterfaceNames: []string{msgInterfaceName}}, nil
+}
+
+func (legacyReflection) ListImplementations(_ context.Context, req *reflectionv1beta1.ListImplementationsRequest) (*reflectionv1beta1.ListImplementationsResponse, error) {
+	return &reflectionv1beta1.ListImplementationsResponse{ImplementationMessageNames: []string{"/cosmos.bank.v1beta1.MsgSend"}}, nil
+}
+
+// legacyNode reports the chain ID through the tendermint service.
+type legacyNode struct {
+	tendermintv1beta1.UnimplementedServiceServer
+}
+
+func (legacyNode) GetNodeInfo(context.Context, *tendermintv1beta1.GetNodeInfoRequest) (*tendermintv1beta1.GetNodeInfoResponse, error) {
+	return &tendermintv1beta1.GetNodeInfoResponse{NodeInfo: &p2p.NodeInfo{Network: "legacy-1"}}, nil
+}
+
+func TestDialConn_WithoutV2Alpha1Reflection(t *testing.T) {
+	conn := testutil.NewConn(t, func(srv *grpc.Server) {
+		reflectionv1beta1.RegisterReflectionServiceServer(srv, &legacyReflection{})
+		tendermintv1beta1.RegisterServiceServer(srv, &legacyNode{})
+		bankv1beta1.RegisterQueryServer(srv, &bankv1beta1.UnimplementedQueryServer{})
+		// not a query service
+		txv1beta1.RegisterServiceServer(srv, &txv1beta1.UnimplementedServiceServer{})
+	})
+
+	c, err := DialConn(context.Background(), conn, "", WithBech32Prefix("legacy"))
+	require.NoError(t, err)
+
+	require.Equal(t, "legacy-1", c.App.Chain.Id)
+	require.Equal(t, "legacy", c.App.Configuration.Bech32AccountAddressPrefix)
+	require.Equal(t, "/cosmos.bank.v1beta1.MsgSend", c.App.Tx.Msgs[0].MsgTypeUrl)
+	require.Equal(t, "cosmos.bank.v1beta1.MsgSend", c.App.Codec.Interfaces[0].InterfaceImplementers[0].Fullname)
+
+	var services []string
+	for _, svc := range c.App.QueryServices.QueryServices {
+		services = append(services, svc.Fullname)
+	}
+	require.Equal(t, []string{"cosmos.bank.v1beta1.Query", "cosmos.base.reflection.v1beta1.ReflectionService", "cosmos.base.tendermint.v1beta1.Service"}, services)
+	require.Equal(t, "/cosmos.base.tendermint.v1beta1.Service/GetNodeInfo", c.App.QueryServices.QueryServices[2].Methods[0].FullQueryPath)
+
+	// without a prefix the auth module is queried, which this node does not expose
+	_, err = DialConn(context.Background(), conn, "")
+	require.ErrorContains(t, err, "WithBech32Prefix")
+}
@@ -0,0 +1,219 @@
+package dynamic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fdymylja/dynamic-cosmos/codec"
+	"github.com/fdymylja/dynamic-cosmos/protoutil"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// msgSignerOption is the field number of the cosmos.msg.v1.signer message option,
+// which lists the fields containing the signers of a Msg.
+const msgSignerOption protowire.Number = 11110000
+
+// legacySignerFields are the field names used by the sdk modules to hold the signer
+// of a Msg, used when the chain does not declare the cosmos.msg.v1.signer option.
+var legacySignerFields = []protoreflect.Name{
+	"signer", "sender", "from_address", "authority", "delegator_address", "validator_address",
+	"validator_addr", "proposer", "voter", "depositor", "granter", "grantee", "admin", "creator", "owner",
+}
+
+// WithLazyCatalog defers building the Client Catalog, and resolving the descriptors
+// of every query service and Msg of the chain, until Client.Catalog is first called.
+func WithLazyCatalog() DialOption {
+	return func(options *options) {
+		options.lazyCatalog = true
+	}
+}
+
+// QueryService describes a query service exposed by the chain.
+type QueryService struct {
+	Name protoreflect.FullName
+	// IsModule reports if the service is the query service of an sdk module.
+	IsModule   bool
+	Descriptor protoreflect.ServiceDescriptor
+	Methods    []*QueryMethod
+}
+
+// QueryMethod describes a method of a query service.
+type QueryMethod struct {
+	Service protoreflect.FullName
+	Name    protoreflect.Name
+	// Path is the method full path used to invoke it, ex: /cosmos.bank.v1beta1.Query/AllBalances.
+	Path       string
+	Descriptor protoreflect.MethodDescriptor
+	Request    protoreflect.MessageDescriptor
+	Response   protoreflect.MessageDescriptor
+}
+
+// Msg describes a Msg type supported by the chain.
+type Msg struct {
+	TypeURL    string
+	Name       protoreflect.FullName
+	Descriptor protoreflect.MessageDescriptor
+	Type       protoreflect.MessageType
+	// Signers are the fields containing the signers addresses of the Msg, taken from
+	// the cosmos.msg.v1.signer option or, when missing, from the fields conventionally
+	// used by the sdk modules. It is empty if the signers could not be determined.
+	Signers []protoreflect.FieldDescriptor
+}
+
+// Catalog lists the query services and Msgs supported by the chain.
+type Catalog struct {
+	services       []*QueryService
+	servicesByName map[protoreflect.FullName]*QueryService
+	methodsByPath  map[string]*QueryMethod
+	msgs           []*Msg
+	msgsByName     map[protoreflect.FullName]*Msg
+}
+
+// QueryServices returns the query services, in the order of the app descriptor.
+func (c *Catalog) QueryServices() []*QueryService {
+	return c.services
+}
+
+// QueryService returns the query service given its full name.
+func (c *Catalog) QueryService(name protoreflect.FullName) (*QueryService, bool) {
+	svc, ok := c.servicesByName[name]
+	return svc, ok
+}
+
+// QueryMethod returns the query method given its path, which can be in the form
+// /cosmos.bank.v1beta1.Query/AllBalances, cosmos.bank.v1beta1.Query/AllBalances
+// or cosmos.bank.v1beta1.Query.AllBalances.
+func (c *Catalog) QueryMethod(path string) (*QueryMethod, bool) {
+	service, method, ok := splitMethodPath(path)
+	if !ok {
+		return nil, false
+	}
+	m, ok := c.methodsByPath[fmt.Sprintf("/%s/%s", service, method)]
+	return m, ok
+}
+
+// splitMethodPath splits a method path in the form /pkg.Service/Method,
+// pkg.Service/Method or pkg.Service.Method in its service and method names.
+func splitMethodPath(path string) (protoreflect.FullName, protoreflect.Name, bool) {
+	path = strings.TrimPrefix(path, "/")
+	i := strings.LastIndexByte(path, '/')
+	if i < 0 {
+		i = strings.LastIndexByte(path, '.')
+	}
+	if i <= 0 || i == len(path)-1 {
+		return "", "", false
+	}
+	return protoreflect.FullName(path[:i]), protoreflect.Name(path[i+1:]), true
+}
+
+// Msgs returns the Msgs supported by the chain, in the order of the app descriptor.
+func (c *Catalog) Msgs() []*Msg {
+	return c.msgs
+}
+
+// Msg returns the Msg given its full name or type URL.
+func (c *Catalog) Msg(name string) (*Msg, bool) {
+	msg, ok := c.msgsByName[protoutil.FullNameFromURL(name)]
+	return msg, ok
+}
+
+// Catalog returns the Catalog of the chain. Unless WithLazyCatalog is provided
+// it is built during Dial, otherwise it is built on the first successful call.
+func (c *Client) Catalog() (*Catalog, error) {
+	c.catalogMu.Lock()
+	defer c.catalogMu.Unlock()
+
+	if c.catalog != nil {
+		return c.catalog, nil
+	}
+	catalog, err := c.prepare()
+	if err != nil {
+		return nil, err
+	}
+	c.catalog = catalog
+	return catalog, nil
+}
+
+// prepare builds the Catalog resolving the query services and Msgs of the app descriptor.
+func (c *Client) prepare() (*Catalog, error) {
+	catalog := &Catalog{
+		servicesByName: map[protoreflect.FullName]*QueryService{},
+		methodsByPath:  map[string]*QueryMethod{},
+		msgsByName:     map[protoreflect.FullName]*Msg{},
+	}
+	// fetch query services
+	for _, svc := range c.App.GetQueryServices().GetQueryServices() {
+		desc, err := c.Codec.Registry.FindDescriptorByName(protoreflect.FullName(svc.Fullname))
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch information for query service %s: %w", svc.Fullname, err)
+		}
+		sd, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a service", svc.Fullname)
+		}
+
+		service := &QueryService{Name: sd.FullName(), IsModule: svc.IsModule, Descriptor: sd}
+		for i := 0; i < sd.Methods().Len(); i++ {
+			md := sd.Methods().Get(i)
+			method := &QueryMethod{
+				Service:    sd.FullName(),
+				Name:       md.Name(),
+				Path:       fmt.Sprintf("/%s/%s", sd.FullName(), md.Name()),
+				Descriptor: md,
+				Request:    md.Input(),
+				Response:   md.Output(),
+			}
+			service.Methods = append(service.Methods, method)
+			catalog.methodsByPath[method.Path] = method
+		}
+		catalog.services = append(catalog.services, service)
+		catalog.servicesByName[service.Name] = service
+	}
+	// fetch messages
+	for _, msg := range c.App.GetTx().GetMsgs() {
+		name := protoutil.FullNameFromURL(msg.MsgTypeUrl)
+
+		desc, err := c.Codec.Registry.FindDescriptorByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch information for message %s: %w", msg.MsgTypeUrl, err)
+		}
+		md, ok := desc.(protoreflect.MessageDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a message", msg.MsgTypeUrl)
+		}
+
+		m := &Msg{
+			TypeURL:    msg.MsgTypeUrl,
+			Name:       md.FullName(),
+			Descriptor: md,
+			Type:       dynamicpb.NewMessageType(md),
+			Signers:    signerFields(md),
+		}
+		catalog.msgs = append(catalog.msgs, m)
+		catalog.msgsByName[m.Name] = m
+	}
+
+	return catalog, nil
+}
+
+// signerFields returns the signer fields of the Msg.
+func signerFields(md protoreflect.MessageDescriptor) []protoreflect.FieldDescriptor {
+	var signers []protoreflect.FieldDescriptor
+	for _, name := range codec.StringOptions(md.Options(), msgSignerOption) {
+		if fd := md.Fields().ByName(protoreflect.Name(name)); fd != nil {
+			signers = append(signers, fd)
+		}
+	}
+	if len(signers) != 0 {
+		return signers
+	}
+
+	for _, name := range legacySignerFields {
+		if fd := md.Fields().ByName(name); fd != nil && fd.Kind() == protoreflect.StringKind && !fd.IsList() {
+			return []protoreflect.FieldDescriptor{fd}
+		}
+	}
+	return nil
+}
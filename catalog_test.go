@@ -0,0 +1,106 @@
+package dynamic
+
+import (
+	"context"
+	"testing"
+
+	_ "github.com/cosmos/cosmos-sdk/api/cosmos/bank/v1beta1"
+	reflectionv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/reflection/v1beta1"
+	tendermintv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+	"github.com/fdymylja/dynamic-cosmos/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestClient_Catalog(t *testing.T) {
+	conn := testutil.NewConn(t, func(srv *grpc.Server) {
+		reflectionv1beta1.RegisterReflectionServiceServer(srv, &legacyReflection{})
+		tendermintv1beta1.RegisterServiceServer(srv, &legacyNode{})
+	})
+
+	c, err := DialConn(context.Background(), conn, "", WithBech32Prefix("legacy"), WithLazyCatalog())
+	require.NoError(t, err)
+	defer c.Close()
+
+	catalog, err := c.Catalog()
+	require.NoError(t, err)
+	require.Len(t, catalog.QueryServices(), 2)
+
+	svc, ok := catalog.QueryService("cosmos.base.tendermint.v1beta1.Service")
+	require.True(t, ok)
+	require.False(t, svc.IsModule)
+
+	for _, path := range []string{
+		"/cosmos.base.tendermint.v1beta1.Service/GetNodeInfo",
+		"cosmos.base.tendermint.v1beta1.Service/GetNodeInfo",
+		"cosmos.base.tendermint.v1beta1.Service.GetNodeInfo",
+	} {
+		method, ok := catalog.QueryMethod(path)
+		require.True(t, ok, path)
+		require.Equal(t, "/cosmos.base.tendermint.v1beta1.Service/GetNodeInfo", method.Path)
+		require.Equal(t, "cosmos.base.tendermint.v1beta1.GetNodeInfoRequest", string(method.Request.FullName()))
+		require.Equal(t, "cosmos.base.tendermint.v1beta1.GetNodeInfoResponse", string(method.Response.FullName()))
+	}
+	_, ok = catalog.QueryMethod("cosmos.base.tendermint.v1beta1.Service/Missing")
+	require.False(t, ok)
+
+	msg, ok := catalog.Msg("/cosmos.bank.v1beta1.MsgSend")
+	require.True(t, ok)
+	same, ok := catalog.Msg("cosmos.bank.v1beta1.MsgSend")
+	require.True(t, ok)
+	require.Same(t, msg, same)
+	require.Len(t, msg.Signers, 1)
+	require.Equal(t, "from_address", string(msg.Signers[0].Name()))
+	require.Equal(t, msg.Descriptor, msg.Type.New().Descriptor())
+}
+
+func Test_signerFields(t *testing.T) {
+	// the signer option is set both resolved and as unknown field
+	ext, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("cosmos/msg/v1/msg.proto"),
+		Package:    proto.String("cosmos.msg.v1"),
+		Dependency: []string{"google/protobuf/descriptor.proto"},
+		Extension: []*descriptorpb.FieldDescriptorProto{{
+			Name:     proto.String("signer"),
+			Number:   proto.Int32(int32(msgSignerOption)),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			Extendee: proto.String(".google.protobuf.MessageOptions"),
+		}},
+	}, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	signerExt := dynamicpb.NewExtensionType(ext.Extensions().Get(0))
+
+	opts := new(descriptorpb.MessageOptions)
+	signers := signerExt.New().List()
+	signers.Append(protoreflect.ValueOfString("admin"))
+	opts.ProtoReflect().Set(signerExt.TypeDescriptor(), protoreflect.ValueOfList(signers))
+	opts.ProtoReflect().SetUnknown(protowire.AppendString(protowire.AppendTag(nil, msgSignerOption, protowire.BytesType), "owner"))
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/v1/msg.proto"),
+		Package: proto.String("test.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name:    proto.String("MsgUpdate"),
+			Options: opts,
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("admin"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()},
+				{Name: proto.String("owner"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()},
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+
+	fields := signerFields(file.Messages().Get(0))
+	require.Len(t, fields, 2)
+	require.Equal(t, protoreflect.Name("admin"), fields[0].Name())
+	require.Equal(t, protoreflect.Name("owner"), fields[1].Name())
+}
@@ -0,0 +1,104 @@
+package dynamic
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	basev1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/v1beta1"
+	"github.com/fdymylja/dynamic-cosmos/chainregistry"
+)
+
+// GasPrice is the price of a unit of gas in the denom.
+type GasPrice struct {
+	Denom  string
+	Amount *big.Rat
+}
+
+// WithChainRegistry sets the directory of the local chain-registry copy used by DialChain.
+func WithChainRegistry(dir string) DialOption {
+	return func(options *options) {
+		options.chainRegistryDir = dir
+	}
+}
+
+// WithGasPrice sets the gas price used by Client.EstimateFee,
+// overriding the one provided by the chain-registry.
+func WithGasPrice(denom string, amount *big.Rat) DialOption {
+	return func(options *options) {
+		options.gasPrice = &GasPrice{Denom: denom, Amount: amount}
+	}
+}
+
+// DialChain dials the chain using the metadata found in the local chain-registry copy
+// set using WithChainRegistry: all its gRPC and RPC endpoints are used, see DialEndpoints,
+// and its first fee token average gas price is used to estimate fees, unless WithGasPrice
+// is provided. The chain ID and bech32 prefix are checked against the ones of the node.
+// TLS is not enabled automatically for registry endpoints which require it, WithTLS must
+// be provided.
+func DialChain(ctx context.Context, chainName string, dialOptions ...DialOption) (*Client, error) {
+	opts := newOptions(nil, nil)
+	for _, o := range dialOptions {
+		o(opts)
+	}
+
+	if opts.chainRegistryDir == "" {
+		return nil, fmt.Errorf("no chain registry directory set")
+	}
+	chain, err := chainregistry.Load(opts.chainRegistryDir, chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.chain = chain
+	opts.grpcEndpoints = chain.GRPCEndpoints()
+	opts.tendermintEndpoints = chain.RPCEndpoints()
+	if opts.gasPrice == nil && len(chain.Fees.FeeTokens) != 0 {
+		denom := chain.Fees.FeeTokens[0].Denom
+		amount, err := chain.GasPrice(denom)
+		if err != nil {
+			return nil, err
+		}
+		opts.gasPrice = &GasPrice{Denom: denom, Amount: amount}
+	}
+
+	return opts.setup(ctx)
+}
+
+// EstimateFee returns the fee paying gasLimit at the gas price set
+// using WithGasPrice or provided by the chain-registry.
+func (c *Client) EstimateFee(gasLimit uint64) ([]*basev1beta1.Coin, error) {
+	if c.gasPrice == nil {
+		return nil, fmt.Errorf("no gas price set")
+	}
+	return []*basev1beta1.Coin{{
+		Denom:  c.gasPrice.Denom,
+		Amount: chainregistry.Fee(gasLimit, c.gasPrice.Amount),
+	}}, nil
+}
+
+// HDPath returns the BIP44 derivation path of the account key, using the chain-registry
+// slip44 coin type if the Client was dialed using DialChain, or the cosmos one.
+func (c *Client) HDPath(account, index uint32) string {
+	chain := c.Chain
+	if chain == nil {
+		chain = new(chainregistry.Chain)
+	}
+	return chain.HDPath(account, index)
+}
+
+// checkChain cross checks the chain-registry metadata against the app descriptor.
+func (o *options) checkChain() error {
+	if o.chain == nil {
+		return nil
+	}
+
+	if id := o.appDesc.GetChain().GetId(); id != "" && id != o.chain.ChainID {
+		return fmt.Errorf("chain registry chain ID %s does not match node chain ID %s", o.chain.ChainID, id)
+	}
+	prefix := o.appDesc.GetConfiguration().GetBech32AccountAddressPrefix()
+	if prefix != "" && o.chain.Bech32Prefix != "" && prefix != o.chain.Bech32Prefix {
+		return fmt.Errorf("chain registry bech32 prefix %s does not match node bech32 prefix %s", o.chain.Bech32Prefix, prefix)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package dynamic
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+func TestDialChain(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	reflection.Register(srv)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "testchain"), 0o755))
+	chainJSON := fmt.Sprintf(`{
+		"chain_name": "testchain",
+		"chain_id": "test-1",
+		"bech32_prefix": "cosmos",
+		"slip44": 529,
+		"fees": {"fee_tokens": [{"denom": "utest", "average_gas_price": 0.025}]},
+		"apis": {"grpc": [{"address": %q}]}
+	}`, lis.Addr().String())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "testchain", "chain.json"), []byte(chainJSON), 0o600))
+
+	c, err := DialChain(context.Background(), "testchain", WithChainRegistry(dir), WithAppDescriptor(testAppDescriptor()))
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.Equal(t, "test-1", c.Chain.ChainID)
+	fee, err := c.EstimateFee(200000)
+	require.NoError(t, err)
+	require.Equal(t, "utest", fee[0].Denom)
+	require.Equal(t, "5000", fee[0].Amount)
+	require.Equal(t, "m/44'/529'/0'/0/0", c.HDPath(0, 0))
+
+	// the node chain ID must match the registry one
+	desc := testAppDescriptor()
+	desc.Chain.Id = "other-1"
+	_, err = DialChain(context.Background(), "testchain", WithChainRegistry(dir), WithAppDescriptor(desc))
+	require.ErrorContains(t, err, "does not match")
+
+	// a fee token without gas prices can't be used to estimate fees
+	chainJSON = strings.Replace(chainJSON, `, "average_gas_price": 0.025`, "", 1)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "testchain", "chain.json"), []byte(chainJSON), 0o600))
+	_, err = DialChain(context.Background(), "testchain", WithChainRegistry(dir), WithAppDescriptor(testAppDescriptor()))
+	require.ErrorContains(t, err, "no gas price")
+}
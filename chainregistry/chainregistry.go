@@ -0,0 +1,149 @@
+// Package chainregistry reads chain metadata from a local copy of the
+// cosmos chain-registry (https://github.com/cosmos/chain-registry).
+package chainregistry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+)
+
+// defaultSlip44 is the cosmos coin type.
+const defaultSlip44 = 118
+
+// Chain is the subset of a chain-registry chain.json used to connect to a chain.
+type Chain struct {
+	ChainName    string `json:"chain_name"`
+	ChainID      string `json:"chain_id"`
+	Bech32Prefix string `json:"bech32_prefix"`
+	Slip44       uint32 `json:"slip44"`
+	Fees         Fees   `json:"fees"`
+	APIs         APIs   `json:"apis"`
+}
+
+// Fees lists the tokens accepted to pay fees.
+type Fees struct {
+	FeeTokens []FeeToken `json:"fee_tokens"`
+}
+
+// FeeToken defines the gas prices of a fee token, which
+// are kept as decimals to not lose precision.
+type FeeToken struct {
+	Denom            string      `json:"denom"`
+	FixedMinGasPrice json.Number `json:"fixed_min_gas_price"`
+	LowGasPrice      json.Number `json:"low_gas_price"`
+	AverageGasPrice  json.Number `json:"average_gas_price"`
+	HighGasPrice     json.Number `json:"high_gas_price"`
+}
+
+// APIs lists the public endpoints of the chain.
+type APIs struct {
+	RPC  []Endpoint `json:"rpc"`
+	REST []Endpoint `json:"rest"`
+	GRPC []Endpoint `json:"grpc"`
+}
+
+// Endpoint is a public endpoint of the chain.
+type Endpoint struct {
+	Address  string `json:"address"`
+	Provider string `json:"provider"`
+}
+
+// Load reads the chain.json of the chain from the chain-registry
+// directory, looking into the testnets directory too.
+func Load(dir, chainName string) (*Chain, error) {
+	if chainName == "" || chainName != filepath.Base(chainName) {
+		return nil, fmt.Errorf("chainregistry: invalid chain name %q", chainName)
+	}
+
+	var (
+		b   []byte
+		err error
+	)
+	for _, path := range []string{
+		filepath.Join(dir, chainName, "chain.json"),
+		filepath.Join(dir, "testnets", chainName, "chain.json"),
+	} {
+		b, err = os.ReadFile(path)
+		if err == nil || !errors.Is(err, os.ErrNotExist) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("chainregistry: unable to read chain %s: %w", chainName, err)
+	}
+
+	chain := new(Chain)
+	err = json.Unmarshal(b, chain)
+	if err != nil {
+		return nil, fmt.Errorf("chainregistry: unable to decode chain %s: %w", chainName, err)
+	}
+	return chain, nil
+}
+
+// GRPCEndpoints returns the addresses of the gRPC endpoints.
+func (c *Chain) GRPCEndpoints() []string {
+	return addresses(c.APIs.GRPC)
+}
+
+// RPCEndpoints returns the addresses of the tendermint RPC endpoints.
+func (c *Chain) RPCEndpoints() []string {
+	return addresses(c.APIs.RPC)
+}
+
+// GasPrice returns the average gas price of the fee token, falling back to the other
+// prices if it's not set. It fails if the token is not a fee token or has no prices.
+func (c *Chain) GasPrice(denom string) (*big.Rat, error) {
+	for _, token := range c.Fees.FeeTokens {
+		if token.Denom != denom {
+			continue
+		}
+		for _, price := range []json.Number{token.AverageGasPrice, token.LowGasPrice, token.FixedMinGasPrice, token.HighGasPrice} {
+			if price == "" {
+				continue
+			}
+			p, ok := new(big.Rat).SetString(price.String())
+			if !ok {
+				return nil, fmt.Errorf("chainregistry: invalid gas price %s of %s", price, denom)
+			}
+			if p.Sign() > 0 {
+				return p, nil
+			}
+		}
+		return nil, fmt.Errorf("chainregistry: fee token %s has no gas price", denom)
+	}
+	return nil, fmt.Errorf("chainregistry: %s is not a fee token of %s", denom, c.ChainName)
+}
+
+// HDPath returns the BIP44 derivation path of the account key, using the
+// chain slip44 coin type, or the cosmos one if it's not set.
+func (c *Chain) HDPath(account, index uint32) string {
+	coinType := c.Slip44
+	if coinType == 0 {
+		coinType = defaultSlip44
+	}
+	return fmt.Sprintf("m/44'/%d'/%d'/0/%d", coinType, account, index)
+}
+
+// Fee returns the fee amount, rounded up, paying gasLimit at gasPrice.
+func Fee(gasLimit uint64, gasPrice *big.Rat) string {
+	fee := new(big.Rat).Mul(new(big.Rat).SetUint64(gasLimit), gasPrice)
+	q, m := new(big.Int).QuoRem(fee.Num(), fee.Denom(), new(big.Int))
+	if m.Sign() > 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q.String()
+}
+
+func addresses(endpoints []Endpoint) []string {
+	addrs := make([]string, 0, len(endpoints))
+	for _, e := range endpoints {
+		if e.Address != "" {
+			addrs = append(addrs, e.Address)
+		}
+	}
+	return addrs
+}
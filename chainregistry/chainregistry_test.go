@@ -0,0 +1,57 @@
+package chainregistry
+
+import (
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const chainJSON = `{
+  "chain_name": "testchain",
+  "chain_id": "test-1",
+  "bech32_prefix": "test",
+  "slip44": 118,
+  "fees": {"fee_tokens": [{"denom": "utest", "low_gas_price": 0.01, "average_gas_price": 0.025}, {"denom": "unoprice"}]},
+  "apis": {
+    "rpc": [{"address": "https://rpc.test.network", "provider": "test"}],
+    "grpc": [{"address": "grpc.test.network:443", "provider": "test"}, {"address": "", "provider": "empty"}]
+  }
+}`
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "testnets", "testchain"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "testnets", "testchain", "chain.json"), []byte(chainJSON), 0o600))
+
+	chain, err := Load(dir, "testchain")
+	require.NoError(t, err)
+	require.Equal(t, "test-1", chain.ChainID)
+	require.Equal(t, "test", chain.Bech32Prefix)
+	require.Equal(t, uint32(118), chain.Slip44)
+	require.Equal(t, []string{"grpc.test.network:443"}, chain.GRPCEndpoints())
+	require.Equal(t, []string{"https://rpc.test.network"}, chain.RPCEndpoints())
+
+	require.Equal(t, "m/44'/118'/0'/0/1", chain.HDPath(0, 1))
+
+	price, err := chain.GasPrice("utest")
+	require.NoError(t, err)
+	require.Equal(t, big.NewRat(25, 1000), price)
+	_, err = chain.GasPrice("uother")
+	require.Error(t, err)
+	_, err = chain.GasPrice("unoprice")
+	require.ErrorContains(t, err, "no gas price")
+
+	require.Equal(t, "5000", Fee(200000, price))
+	require.Equal(t, "1", Fee(1, price))
+	// large amounts don't lose precision
+	require.Equal(t, "461168601842738791", Fee(math.MaxUint64, price))
+
+	_, err = Load(dir, "missing")
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = Load(dir, "../testchain")
+	require.Error(t, err)
+}
@@ -2,40 +2,66 @@ package dynamic
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"sync"
 
 	txv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/tx/v1beta1"
 	"github.com/fdymylja/dynamic-cosmos/tx"
 
 	"github.com/cosmos/cosmos-sdk/api/cosmos/base/reflection/v2alpha1"
+	"github.com/fdymylja/dynamic-cosmos/chainregistry"
 	"github.com/fdymylja/dynamic-cosmos/codec"
-	"github.com/fdymylja/dynamic-cosmos/protoutil"
 	"github.com/tendermint/tendermint/rpc/client/http"
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/proto"
-	"google.golang.org/protobuf/reflect/protoreflect"
-	"google.golang.org/protobuf/types/dynamicpb"
 )
 
+// ErrNoTendermint is returned when using features which require
+// a tendermint RPC endpoint on a query only Client.
+var ErrNoTendermint = errors.New("no tendermint endpoint set, the client is query only")
+
 type Client struct {
 	App       *reflectionv2alpha1.AppDescriptor
 	Codec     *codec.Codec
 	Addresses *Addresses
-
-	dynQueriers map[protoreflect.FullName]protoreflect.ServiceDescriptor
-	dynMessage  map[protoreflect.FullName]protoreflect.MessageType
-
-	tm      *http.HTTP
-	grpc    grpc.ClientConnInterface
-	watcher *tx.Watcher
-	txSvc   txv1beta1.ServiceClient
-
-	authOpt *authenticationOptions
+	// Chain is the chain-registry metadata, set only if dialed using DialChain.
+	Chain *chainregistry.Chain
+
+	catalogMu sync.Mutex
+	catalog   *Catalog
+
+	tm         *tmPool
+	grpc       grpc.ClientConnInterface
+	connCloser io.Closer
+	health     *healthMonitor
+	watcher    *tx.Watcher
+	txSvc      txv1beta1.ServiceClient
+
+	authOpt   *authenticationOptions
+	gasPrice  *GasPrice
+	appHashes AppHashProvider
+	light     *LightClient
+
+	closeOnce sync.Once
+	closeErr  error
 }
 
+// Dial connects to the chain gRPC endpoint and, if tmEndpoint is not empty, to its
+// tendermint RPC endpoint. Without a tendermint endpoint the Client is query only:
+// broadcast transactions are tracked by polling the gRPC tx service.
 func Dial(ctx context.Context, grpcEndpoint string, tmEndpoint string, dialOptions ...DialOption) (*Client, error) {
-	opts := newOptions(grpcEndpoint, tmEndpoint)
+	return DialEndpoints(ctx, endpointList(grpcEndpoint), endpointList(tmEndpoint), dialOptions...)
+}
+
+// DialEndpoints is like Dial but accepts multiple gRPC and tendermint endpoints of
+// the same chain. Nodes are periodically health checked: queries are routed to the
+// first healthy gRPC node and fail over to the others when a node is unavailable,
+// and the tx watcher subscription moves to another tendermint node when the current
+// one is unhealthy. Nodes catching up or lagging behind are avoided, see WithMaxBlockLag.
+func DialEndpoints(ctx context.Context, grpcEndpoints []string, tmEndpoints []string, dialOptions ...DialOption) (*Client, error) {
+	opts := newOptions(grpcEndpoints, tmEndpoints)
 	for _, o := range dialOptions {
 		o(opts)
 	}
@@ -43,34 +69,30 @@ func Dial(ctx context.Context, grpcEndpoint string, tmEndpoint string, dialOptio
 	return opts.setup(ctx)
 }
 
-// TODO(fdymylja): decide what to do with this
-func (c *Client) prepare() error {
-	// fetch query services
-	for _, svc := range c.App.QueryServices.QueryServices {
-		desc, err := c.Codec.Registry.FindDescriptorByName(protoreflect.FullName(svc.Fullname))
-		if err != nil {
-			return fmt.Errorf("unable to fetch information for query service %s: %w", svc.Fullname, err)
-		}
-
-		c.dynQueriers[protoreflect.FullName(svc.Fullname)] = desc.(protoreflect.ServiceDescriptor)
+// DialConn creates a Client using an existing gRPC connection, which is also used
+// to resolve protobuf files through gRPC reflection unless WithRemoteRegistry is
+// provided. The connection is not closed by Client.Close. Options configuring
+// how gRPC connections are dialed are ignored.
+func DialConn(ctx context.Context, conn grpc.ClientConnInterface, tmEndpoint string, dialOptions ...DialOption) (*Client, error) {
+	opts := newOptions(nil, endpointList(tmEndpoint))
+	for _, o := range dialOptions {
+		o(opts)
 	}
-	// fetch messages
-	for _, msg := range c.App.Tx.Msgs {
-		message := protoutil.FullNameFromURL(msg.MsgTypeUrl)
 
-		md, err := c.Codec.Registry.FindDescriptorByName(message)
-		if err != nil {
-			return fmt.Errorf("unable to fetch information for message %s: %w", msg.MsgTypeUrl, err)
-		}
+	return opts.setupConn(ctx, conn)
+}
 
-		c.dynMessage[md.FullName()] = dynamicpb.NewMessageType(md.(protoreflect.MessageDescriptor))
+func endpointList(endpoint string) []string {
+	if endpoint == "" {
+		return nil
 	}
-
-	return nil
+	return []string{endpoint}
 }
 
-func (c *Client) DynamicQuery(ctx context.Context, method string, req, resp proto.Message) (err error) {
-	return c.grpc.Invoke(ctx, method, req, resp)
+// DynamicQuery invokes the query method, ex: /cosmos.bank.v1beta1.Query/AllBalances.
+// By default the latest state is queried, see AtHeight.
+func (c *Client) DynamicQuery(ctx context.Context, method string, req, resp proto.Message, opts ...QueryOption) (err error) {
+	return invokeQuery(ctx, c.grpc, method, req, resp, newQueryOptions(opts))
 }
 
 func (c *Client) NewTx() *Tx {
@@ -81,30 +103,50 @@ func (c *Client) ClientConn() grpc.ClientConnInterface {
 	return c.grpc
 }
 
-func (c *Client) Close() error {
-	var reasons []error
-
-	err := c.tm.Stop()
-	if err != nil {
-		reasons = append(reasons, err)
+// Tendermint returns the tendermint RPC client, or ErrNoTendermint
+// if the Client was dialed without a tendermint endpoint.
+func (c *Client) Tendermint() (*http.HTTP, error) {
+	if c.tm == nil {
+		return nil, ErrNoTendermint
 	}
+	return c.tm.get(), nil
+}
 
-	c.watcher.Stop()
-	if closer, ok := c.grpc.(io.Closer); ok {
-		err = closer.Close()
+// Close stops the health checks, the tx watcher, closing the channels of the pending
+// watches, the tendermint client and the light client, then closes the protobuf file remote and the gRPC
+// connection, unless it was provided using DialConn. Every failure is reported using
+// a *MultiError. Close can be called multiple times and always returns the same result.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		c.closeErr = c.close()
+	})
+	return c.closeErr
+}
+
+func (c *Client) close() error {
+	var errs []error
+	wrap := func(msg string, err error) {
 		if err != nil {
-			reasons = append(reasons, err)
+			errs = append(errs, fmt.Errorf("%s: %w", msg, err))
 		}
 	}
 
-	err = c.Codec.Registry.Remote().Close() // TODO better
-	if err != nil {
-		reasons = append(reasons, err)
+	if c.health != nil {
+		c.health.stop()
 	}
-
-	if len(reasons) == 0 {
-		return nil
+	if c.watcher != nil {
+		wrap("unable to stop tx watcher", c.watcher.Stop())
+	}
+	if c.tm != nil {
+		wrap("unable to stop tendermint client", c.tm.stop())
+	}
+	if c.light != nil {
+		wrap("unable to close light client", c.light.Close())
+	}
+	wrap("unable to close protobuf file remote", c.Codec.Registry.Remote().Close())
+	if c.connCloser != nil {
+		wrap("unable to close grpc connection", c.connCloser.Close())
 	}
 
-	return reasons[0] // TODO better
+	return joinErrors(errs...)
 }
@@ -8,6 +8,8 @@ import (
 	queryv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/query/v1beta1"
 	govv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/gov/v1beta1"
 	"github.com/fdymylja/dynamic-cosmos/codec"
+	"github.com/fdymylja/dynamic-cosmos/internal/testutil"
+	"google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/stretchr/testify/require"
 )
@@ -17,14 +19,16 @@ func TestNewClient(t *testing.T) {
 
 	c, err := Dial(context.Background(), "34.94.191.28:9090", "")
 	require.NoError(t, err)
-	for k, svc := range c.dynQueriers {
-		t.Logf("%s", k)
-		t.Logf(svc.ParentFile().Path())
-		t.Log(svc.ParentFile().FullName())
+	catalog, err := c.Catalog()
+	require.NoError(t, err)
+	for _, svc := range catalog.QueryServices() {
+		t.Logf("%s", svc.Name)
+		t.Logf(svc.Descriptor.ParentFile().Path())
+		t.Log(svc.Descriptor.ParentFile().FullName())
 	}
 
-	for name, msg := range c.dynMessage {
-		t.Logf("message typeURL: %s, name: %s", name, msg.Descriptor().Name())
+	for _, msg := range catalog.Msgs() {
+		t.Logf("message typeURL: %s, name: %s", msg.TypeURL, msg.Descriptor.Name())
 	}
 
 	// try with cache remote
@@ -78,3 +82,35 @@ func TestInvokeProposal(t *testing.T) {
 
 	t.Logf("%s", jsonBytes)
 }
+
+func TestDialConn_QueryOnly(t *testing.T) {
+	conn := testutil.NewConn(t, nil)
+
+	c, err := DialConn(context.Background(), conn, "", WithAppDescriptor(testAppDescriptor()))
+	require.NoError(t, err)
+
+	_, err = c.Tendermint()
+	require.ErrorIs(t, err, ErrNoTendermint)
+
+	// queries resolve types through reflection on the same connection
+	reqType, err := c.Codec.Registry.FindMessageByName("grpc.health.v1.HealthCheckRequest")
+	require.NoError(t, err)
+	respType, err := c.Codec.Registry.FindMessageByName("grpc.health.v1.HealthCheckResponse")
+	require.NoError(t, err)
+
+	resp := respType.New()
+	err = c.DynamicQuery(context.Background(), "/grpc.health.v1.Health/Check", reqType.New().Interface(), resp.Interface())
+	require.NoError(t, err)
+	require.EqualValues(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Get(resp.Descriptor().Fields().ByName("status")).Enum())
+}
+
+func TestClient_Close(t *testing.T) {
+	conn := testutil.NewConn(t, nil)
+
+	c, err := DialConn(context.Background(), conn, "", WithAppDescriptor(testAppDescriptor()))
+	require.NoError(t, err)
+
+	// the connection is owned by the caller, closing twice is safe
+	require.NoError(t, c.Close())
+	require.NoError(t, c.Close())
+}
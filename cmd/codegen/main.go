@@ -0,0 +1,171 @@
+// Command codegen generates Go packages from the API of a live chain,
+// or from a cached FileDescriptorSet. The message types are generated by
+// protoc-gen-go, which must be installed in PATH or set using -protoc-gen-go.
+//
+// Usage:
+//
+//	codegen -grpc localhost:9090 -prefix example.com/chain -out ./chain
+//	codegen -descriptors osmosis.proto.json -prefix example.com/chain -out ./chain
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	reflectionv2alpha1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/reflection/v2alpha1"
+	"github.com/fdymylja/dynamic-cosmos/codec"
+	"github.com/fdymylja/dynamic-cosmos/codegen"
+	"github.com/fdymylja/dynamic-cosmos/protoutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// mappings collects repeated -map file=importpath flags.
+type mappings map[string]string
+
+func (m mappings) String() string {
+	return fmt.Sprint(map[string]string(m))
+}
+
+func (m mappings) Set(s string) error {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return fmt.Errorf("invalid mapping %q, expected file=importpath", s)
+	}
+	m[s[:i]] = s[i+1:]
+	return nil
+}
+
+func main() {
+	var (
+		grpcEndpoint = flag.String("grpc", "", "gRPC endpoint of the chain to generate code for")
+		descriptors  = flag.String("descriptors", "", "path of a FileDescriptorSet, in protojson if it ends with .json, otherwise in binary")
+		prefix       = flag.String("prefix", "", "Go import path under which packages are generated")
+		out          = flag.String("out", ".", "directory corresponding to the import prefix")
+		only         = flag.String("only", "", "comma separated list of proto file path prefixes to generate, defaults to all")
+		protocGenGo  = flag.String("protoc-gen-go", "", "path of the protoc-gen-go plugin, defaults to the one in PATH")
+		mapped       = mappings{}
+	)
+	flag.Var(mapped, "map", "maps a proto file to an existing Go import path, as file=importpath, can be repeated")
+	flag.Parse()
+
+	var (
+		set *descriptorpb.FileDescriptorSet
+		err error
+	)
+	switch {
+	case *grpcEndpoint != "" && *descriptors != "":
+		log.Fatal("only one of -grpc and -descriptors can be set")
+	case *grpcEndpoint != "":
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		set, err = fetchDescriptors(ctx, *grpcEndpoint)
+		cancel()
+	case *descriptors != "":
+		set, err = readDescriptors(*descriptors)
+	default:
+		log.Fatal("one of -grpc and -descriptors must be set")
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := codegen.Options{
+		ImportPrefix: *prefix,
+		ImportPaths:  mapped,
+		ProtocGenGo:  *protocGenGo,
+	}
+	if *only != "" {
+		prefixes := strings.Split(*only, ",")
+		opts.Filter = func(path string) bool {
+			for _, p := range prefixes {
+				if strings.HasPrefix(path, p) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	files, err := codegen.Generate(set, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = codegen.WriteDir(*out, files)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("generated %d files in %s", len(files), *out)
+}
+
+func readDescriptors(path string) (*descriptorpb.FileDescriptorSet, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	set := new(descriptorpb.FileDescriptorSet)
+	if strings.HasSuffix(path, ".json") {
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, set)
+	} else {
+		err = proto.Unmarshal(b, set)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode descriptors: %w", err)
+	}
+
+	return set, nil
+}
+
+// fetchDescriptors loads the files defining the query services
+// and the transaction messages of the chain.
+func fetchDescriptors(ctx context.Context, endpoint string) (*descriptorpb.FileDescriptorSet, error) {
+	remote, err := codec.NewGRPCReflectionProtoFileRegistry(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer remote.Close()
+
+	registry := codec.NewRegistry(remote)
+
+	conn, err := grpc.DialContext(ctx, endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rc := reflectionv2alpha1.NewReflectionServiceClient(conn)
+	queries, err := rc.GetQueryServicesDescriptor(ctx, &reflectionv2alpha1.GetQueryServicesDescriptorRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch query services: %w", err)
+	}
+	txDesc, err := rc.GetTxDescriptor(ctx, &reflectionv2alpha1.GetTxDescriptorRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch tx descriptor: %w", err)
+	}
+
+	for _, svc := range queries.Queries.QueryServices {
+		_, err = registry.FindDescriptorByName(protoreflect.FullName(svc.Fullname))
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch query service %s: %w", svc.Fullname, err)
+		}
+	}
+	for _, msg := range txDesc.Tx.Msgs {
+		_, err = registry.FindDescriptorByName(protoutil.FullNameFromURL(msg.MsgTypeUrl))
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch msg %s: %w", msg.MsgTypeUrl, err)
+		}
+	}
+
+	return registry.Save()
+}
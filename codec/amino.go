@@ -0,0 +1,726 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// amino and gogoproto option numbers, they're read from the raw
+// options as the chain descriptors might not be resolvable locally.
+const (
+	aminoNameOption          protowire.Number = 11110001 // amino.name, MessageOptions
+	aminoEncodingOption      protowire.Number = 11110003 // amino.encoding, FieldOptions
+	aminoFieldNameOption     protowire.Number = 11110004 // amino.field_name, FieldOptions
+	aminoDontOmitEmptyOption protowire.Number = 11110005 // amino.dont_omitempty, FieldOptions
+
+	gogoNullableOption protowire.Number = 65001 // gogoproto.nullable, FieldOptions
+	gogoJSONTagOption  protowire.Number = 65005 // gogoproto.jsontag, FieldOptions
+)
+
+const (
+	anyFullName       protoreflect.FullName = "google.protobuf.Any"
+	timestampFullName protoreflect.FullName = "google.protobuf.Timestamp"
+	durationFullName  protoreflect.FullName = "google.protobuf.Duration"
+)
+
+// MarshalAminoJSON encodes the message using the legacy amino JSON format. Messages
+// annotated with an amino.name, and Any values, are wrapped as {"type":..., "value":...}.
+// Unset Any values are encoded as null. Fields are emitted in declaration order, sign
+// docs require the output to be sorted with SortJSON.
+func (c *Codec) MarshalAminoJSON(m proto.Message) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := c.aminoMarshalTop(buf, m.ProtoReflect())
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalAminoJSON decodes the legacy amino JSON encoded message into m.
+// Any values are resolved through their amino name, see Registry.FindMessageByAminoName.
+func (c *Codec) UnmarshalAminoJSON(b []byte, m proto.Message) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v interface{}
+	err := dec.Decode(&v)
+	if err != nil {
+		return err
+	}
+
+	msg := m.ProtoReflect()
+	if _, named := aminoName(msg.Descriptor()); named {
+		v, err = unwrapAmino(v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.aminoUnmarshalMessage(v, msg)
+}
+
+// SortJSON returns the JSON with object keys sorted, as
+// required by amino JSON sign docs.
+func SortJSON(b []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v interface{}
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	// encoding/json sorts map keys
+	return json.Marshal(v)
+}
+
+// FindMessageByAminoName returns the message type annotated with the given amino.name
+// among the files known to the Registry. Names which are not found are treated as
+// protobuf full names.
+func (r *Registry) FindMessageByAminoName(name string) (protoreflect.MessageType, error) {
+	var found protoreflect.MessageDescriptor
+	r.rangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		found = findAminoName(fd.Messages(), name)
+		return found == nil
+	})
+	if found != nil {
+		return r.FindMessageByName(found.FullName())
+	}
+
+	return r.FindMessageByName(protoreflect.FullName(name))
+}
+
+func findAminoName(mds protoreflect.MessageDescriptors, name string) protoreflect.MessageDescriptor {
+	for i := 0; i < mds.Len(); i++ {
+		md := mds.Get(i)
+		if n, named := aminoName(md); named && n == name {
+			return md
+		}
+		if nested := findAminoName(md.Messages(), name); nested != nil {
+			return nested
+		}
+	}
+	return nil
+}
+
+func (c *Codec) aminoMarshalTop(buf *bytes.Buffer, msg protoreflect.Message) error {
+	name, named := aminoName(msg.Descriptor())
+	if !named {
+		return c.aminoMarshalMessage(buf, msg)
+	}
+
+	return c.aminoMarshalWrapped(buf, name, msg)
+}
+
+func (c *Codec) aminoMarshalWrapped(buf *bytes.Buffer, name string, msg protoreflect.Message) error {
+	buf.WriteString(`{"type":`)
+	writeJSONString(buf, name)
+	buf.WriteString(`,"value":`)
+	err := c.aminoMarshalMessage(buf, msg)
+	if err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func (c *Codec) aminoMarshalMessage(buf *bytes.Buffer, msg protoreflect.Message) error {
+	switch msg.Descriptor().FullName() {
+	case anyFullName:
+		return c.aminoMarshalAny(buf, msg)
+	case timestampFullName:
+		return aminoMarshalTimestamp(buf, msg)
+	case durationFullName:
+		return aminoMarshalDuration(buf, msg)
+	}
+
+	buf.WriteByte('{')
+	first := true
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		info := c.Registry.aminoField(fd)
+		if info.skip || !msg.Has(fd) && !info.emitEmpty {
+			continue
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		writeJSONString(buf, info.name)
+		buf.WriteByte(':')
+
+		err := c.aminoMarshalField(buf, fd, info, msg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fd.FullName(), err)
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func (c *Codec) aminoMarshalField(buf *bytes.Buffer, fd protoreflect.FieldDescriptor, info aminoFieldInfo, msg protoreflect.Message) error {
+	switch {
+	case fd.IsList():
+		list := msg.Get(fd).List()
+		if list.Len() == 0 && !info.emptyListAsArray {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i := 0; i < list.Len(); i++ {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			err := c.aminoMarshalValue(buf, fd, list.Get(i))
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case fd.IsMap():
+		// amino has no map support, we encode them as objects with sorted keys
+		m := msg.Get(fd).Map()
+		keys := make([]protoreflect.MapKey, 0, m.Len())
+		m.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+			keys = append(keys, key)
+			return true
+		})
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, key.String())
+			buf.WriteByte(':')
+			err := c.aminoMarshalValue(buf, fd.MapValue(), m.Get(key))
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case fd.Message() != nil && !msg.Has(fd) && info.nullable:
+		buf.WriteString("null")
+		return nil
+	default:
+		// non nullable messages which are not set are encoded as their zero value
+		return c.aminoMarshalValue(buf, fd, msg.Get(fd))
+	}
+}
+
+func (c *Codec) aminoMarshalValue(buf *bytes.Buffer, fd protoreflect.FieldDescriptor, v protoreflect.Value) error {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		buf.WriteString(strconv.FormatBool(v.Bool()))
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// amino encodes 64 bits integers as strings
+		writeJSONString(buf, strconv.FormatInt(v.Int(), 10))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		writeJSONString(buf, strconv.FormatUint(v.Uint(), 10))
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("unsupported float value: %v", f)
+		}
+		buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+	case protoreflect.StringKind:
+		writeJSONString(buf, v.String())
+	case protoreflect.BytesKind:
+		writeJSONString(buf, base64.StdEncoding.EncodeToString(v.Bytes()))
+	case protoreflect.EnumKind:
+		// amino encodes enums as their number
+		buf.WriteString(strconv.FormatInt(int64(v.Enum()), 10))
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return c.aminoMarshalMessage(buf, v.Message())
+	default:
+		return fmt.Errorf("unsupported kind: %s", fd.Kind())
+	}
+	return nil
+}
+
+func (c *Codec) aminoMarshalAny(buf *bytes.Buffer, msg protoreflect.Message) error {
+	anyMsg, err := toAny(msg)
+	if err != nil {
+		return err
+	}
+	// unset Any values are nil interfaces for amino
+	if anyMsg.TypeUrl == "" {
+		buf.WriteString("null")
+		return nil
+	}
+
+	mt, err := c.Registry.FindMessageByURL(anyMsg.TypeUrl)
+	if err != nil {
+		return fmt.Errorf("unable to resolve any type %s: %w", anyMsg.TypeUrl, err)
+	}
+
+	value := mt.New()
+	err = c.unmarshal.Unmarshal(anyMsg.Value, value.Interface())
+	if err != nil {
+		return err
+	}
+
+	name, named := aminoName(value.Descriptor())
+	if !named {
+		// legacy amino would fail here, we fallback to the protobuf name
+		name = string(value.Descriptor().FullName())
+	}
+
+	return c.aminoMarshalWrapped(buf, name, value)
+}
+
+func aminoMarshalTimestamp(buf *bytes.Buffer, msg protoreflect.Message) error {
+	fields := msg.Descriptor().Fields()
+	seconds := msg.Get(fields.ByName("seconds")).Int()
+	nanos := msg.Get(fields.ByName("nanos")).Int()
+
+	t := time.Unix(seconds, nanos).UTC()
+	writeJSONString(buf, t.Format(time.RFC3339Nano))
+	return nil
+}
+
+func aminoMarshalDuration(buf *bytes.Buffer, msg protoreflect.Message) error {
+	fields := msg.Descriptor().Fields()
+	seconds := msg.Get(fields.ByName("seconds")).Int()
+	nanos := msg.Get(fields.ByName("nanos")).Int()
+
+	// amino encodes durations as nanoseconds, like time.Duration
+	if seconds > math.MaxInt64/int64(time.Second) || seconds < math.MinInt64/int64(time.Second) {
+		return fmt.Errorf("duration out of range: %ds", seconds)
+	}
+	writeJSONString(buf, strconv.FormatInt(seconds*int64(time.Second)+nanos, 10))
+	return nil
+}
+
+func (c *Codec) aminoUnmarshalMessage(v interface{}, msg protoreflect.Message) error {
+	switch msg.Descriptor().FullName() {
+	case anyFullName:
+		return c.aminoUnmarshalAny(v, msg)
+	case timestampFullName:
+		return aminoUnmarshalTimestamp(v, msg)
+	case durationFullName:
+		return aminoUnmarshalDuration(v, msg)
+	}
+
+	if v == nil {
+		return nil
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: expected object, got %T", msg.Descriptor().FullName(), v)
+	}
+
+	fields := msg.Descriptor().Fields()
+	byName := make(map[string]protoreflect.FieldDescriptor, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		info := c.Registry.aminoField(fd)
+		if info.skip {
+			continue
+		}
+		byName[info.name] = fd
+	}
+
+	for name, fieldValue := range obj {
+		fd, exists := byName[name]
+		if !exists {
+			return fmt.Errorf("%s: unknown field %s", msg.Descriptor().FullName(), name)
+		}
+		if fieldValue == nil {
+			continue
+		}
+		err := c.aminoUnmarshalField(fieldValue, fd, msg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fd.FullName(), err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Codec) aminoUnmarshalField(v interface{}, fd protoreflect.FieldDescriptor, msg protoreflect.Message) error {
+	switch {
+	case fd.IsList():
+		array, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected array, got %T", v)
+		}
+		list := msg.Mutable(fd).List()
+		for _, elem := range array {
+			value, err := c.aminoUnmarshalValue(elem, fd, list.NewElement)
+			if err != nil {
+				return err
+			}
+			list.Append(value)
+		}
+		return nil
+	case fd.IsMap():
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected object, got %T", v)
+		}
+		m := msg.Mutable(fd).Map()
+		for k, elem := range obj {
+			key, err := aminoUnmarshalScalar(k, fd.MapKey())
+			if err != nil {
+				return err
+			}
+			value, err := c.aminoUnmarshalValue(elem, fd.MapValue(), m.NewValue)
+			if err != nil {
+				return err
+			}
+			m.Set(key.MapKey(), value)
+		}
+		return nil
+	default:
+		value, err := c.aminoUnmarshalValue(v, fd, func() protoreflect.Value { return msg.NewField(fd) })
+		if err != nil {
+			return err
+		}
+		msg.Set(fd, value)
+		return nil
+	}
+}
+
+func (c *Codec) aminoUnmarshalValue(v interface{}, fd protoreflect.FieldDescriptor, newValue func() protoreflect.Value) (protoreflect.Value, error) {
+	if fd.Message() == nil {
+		return aminoUnmarshalScalar(v, fd)
+	}
+
+	value := newValue()
+	err := c.aminoUnmarshalMessage(v, value.Message())
+	if err != nil {
+		return protoreflect.Value{}, err
+	}
+	return value, nil
+}
+
+func aminoUnmarshalScalar(v interface{}, fd protoreflect.FieldDescriptor) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		switch b := v.(type) {
+		case bool:
+			return protoreflect.ValueOfBool(b), nil
+		case string:
+			// map keys
+			parsed, err := strconv.ParseBool(b)
+			return protoreflect.ValueOfBool(parsed), err
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		i, err := strconv.ParseInt(numberString(v), 10, 32)
+		return protoreflect.ValueOfInt32(int32(i)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := strconv.ParseInt(numberString(v), 10, 64)
+		return protoreflect.ValueOfInt64(i), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		u, err := strconv.ParseUint(numberString(v), 10, 32)
+		return protoreflect.ValueOfUint32(uint32(u)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		u, err := strconv.ParseUint(numberString(v), 10, 64)
+		return protoreflect.ValueOfUint64(u), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(numberString(v), 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(numberString(v), 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.StringKind:
+		if s, ok := v.(string); ok {
+			return protoreflect.ValueOfString(s), nil
+		}
+	case protoreflect.BytesKind:
+		if s, ok := v.(string); ok {
+			b, err := base64.StdEncoding.DecodeString(s)
+			return protoreflect.ValueOfBytes(b), err
+		}
+	case protoreflect.EnumKind:
+		// we accept both numbers, which amino produces, and names
+		if s, ok := v.(string); ok {
+			if vd := fd.Enum().Values().ByName(protoreflect.Name(s)); vd != nil {
+				return protoreflect.ValueOfEnum(vd.Number()), nil
+			}
+		}
+		i, err := strconv.ParseInt(numberString(v), 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i)), err
+	}
+
+	return protoreflect.Value{}, fmt.Errorf("invalid value for %s field: %v", fd.Kind(), v)
+}
+
+func (c *Codec) aminoUnmarshalAny(v interface{}, msg protoreflect.Message) error {
+	if v == nil {
+		return nil
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected {\"type\", \"value\"} object, got %T", v)
+	}
+	name, ok := obj["type"].(string)
+	if !ok {
+		return fmt.Errorf("missing amino type in any")
+	}
+
+	mt, err := c.Registry.FindMessageByAminoName(name)
+	if err != nil {
+		return fmt.Errorf("unable to resolve amino type %s: %w", name, err)
+	}
+
+	value := mt.New()
+	err = c.aminoUnmarshalMessage(obj["value"], value)
+	if err != nil {
+		return err
+	}
+
+	b, err := c.marshal.Marshal(value.Interface())
+	if err != nil {
+		return err
+	}
+
+	fields := msg.Descriptor().Fields()
+	msg.Set(fields.ByName("type_url"), protoreflect.ValueOfString("/"+string(value.Descriptor().FullName())))
+	msg.Set(fields.ByName("value"), protoreflect.ValueOfBytes(b))
+	return nil
+}
+
+func aminoUnmarshalTimestamp(v interface{}, msg protoreflect.Message) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("expected RFC3339 timestamp, got %T", v)
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return err
+	}
+
+	fields := msg.Descriptor().Fields()
+	msg.Set(fields.ByName("seconds"), protoreflect.ValueOfInt64(t.Unix()))
+	msg.Set(fields.ByName("nanos"), protoreflect.ValueOfInt32(int32(t.Nanosecond())))
+	return nil
+}
+
+func aminoUnmarshalDuration(v interface{}, msg protoreflect.Message) error {
+	nanos, err := strconv.ParseInt(numberString(v), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+
+	d := time.Duration(nanos)
+	fields := msg.Descriptor().Fields()
+	msg.Set(fields.ByName("seconds"), protoreflect.ValueOfInt64(int64(d/time.Second)))
+	msg.Set(fields.ByName("nanos"), protoreflect.ValueOfInt32(int32(d%time.Second)))
+	return nil
+}
+
+// unwrapAmino returns the value of a {"type":..., "value":...} object.
+func unwrapAmino(v interface{}) (interface{}, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected {\"type\", \"value\"} object, got %T", v)
+	}
+	if _, ok := obj["type"].(string); !ok {
+		return nil, fmt.Errorf("missing amino type")
+	}
+	return obj["value"], nil
+}
+
+func numberString(v interface{}) string {
+	switch n := v.(type) {
+	case json.Number:
+		return n.String()
+	case string:
+		return n
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s) // strings always marshal
+	buf.Write(b)
+}
+
+func toAny(msg protoreflect.Message) (*anypb.Any, error) {
+	if anyMsg, ok := msg.Interface().(*anypb.Any); ok {
+		return anyMsg, nil
+	}
+
+	fields := msg.Descriptor().Fields()
+	return &anypb.Any{
+		TypeUrl: msg.Get(fields.ByName("type_url")).String(),
+		Value:   msg.Get(fields.ByName("value")).Bytes(),
+	}, nil
+}
+
+type aminoFieldInfo struct {
+	name string
+	// skip is set when the field is excluded from JSON through gogoproto.jsontag.
+	skip bool
+	// emitEmpty is set when the field must be emitted even if it's not populated.
+	emitEmpty bool
+	// emptyListAsArray encodes empty lists as [] instead of null.
+	emptyListAsArray bool
+	// nullable is false for message fields annotated with gogoproto.nullable=false.
+	nullable bool
+}
+
+// aminoField returns the aminoFieldInfo of the field, which is cached until the Registry is refreshed.
+func (r *Registry) aminoField(fd protoreflect.FieldDescriptor) aminoFieldInfo {
+	r.mu.RLock()
+	cache := r.state.aminoFields
+	r.mu.RUnlock()
+
+	if info, cached := cache.Load(fd); cached {
+		return info.(aminoFieldInfo)
+	}
+	info := newAminoFieldInfo(fd)
+	cache.Store(fd, info)
+	return info
+}
+
+func newAminoFieldInfo(fd protoreflect.FieldDescriptor) aminoFieldInfo {
+	info := aminoFieldInfo{
+		name:     string(fd.Name()),
+		nullable: true,
+	}
+	opts := fd.Options()
+
+	if tag, ok := stringOption(opts, gogoJSONTagOption); ok {
+		parts := strings.Split(tag, ",")
+		switch {
+		case parts[0] == "-" && len(parts) == 1:
+			info.skip = true
+		case parts[0] != "":
+			info.name = parts[0]
+		}
+		// a custom json tag without omitempty disables omitting empty values
+		info.emitEmpty = true
+		for _, p := range parts[1:] {
+			if p == "omitempty" {
+				info.emitEmpty = false
+			}
+		}
+	}
+	if name, ok := stringOption(opts, aminoFieldNameOption); ok {
+		info.name = name
+	}
+	if dontOmit, ok := boolOption(opts, aminoDontOmitEmptyOption); ok && dontOmit {
+		info.emitEmpty = true
+	}
+	if nullable, ok := boolOption(opts, gogoNullableOption); ok && !nullable && fd.Message() != nil && !fd.IsList() && !fd.IsMap() {
+		info.nullable = false
+		info.emitEmpty = true
+	}
+	if encoding, ok := stringOption(opts, aminoEncodingOption); ok && encoding == "legacy_coins" {
+		info.emptyListAsArray = true
+	}
+	if info.emitEmpty && fd.IsList() {
+		info.emptyListAsArray = true
+	}
+
+	return info
+}
+
+func aminoName(md protoreflect.MessageDescriptor) (string, bool) {
+	return stringOption(md.Options(), aminoNameOption)
+}
+
+func stringOption(opts proto.Message, num protowire.Number) (string, bool) {
+	b, ok := rawOption(opts, num, protowire.BytesType)
+	if !ok {
+		return "", false
+	}
+	v, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return "", false
+	}
+	return string(v), true
+}
+
+func boolOption(opts proto.Message, num protowire.Number) (bool, bool) {
+	b, ok := rawOption(opts, num, protowire.VarintType)
+	if !ok {
+		return false, false
+	}
+	v, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return false, false
+	}
+	return protowire.DecodeBool(v), true
+}
+
+// StringOptions returns the values of the repeated string option with the given number, such as
+// cosmos.msg.v1.signer, read like the amino options whether the extension was resolved or not.
+func StringOptions(opts proto.Message, num protowire.Number) []string {
+	var values []string
+	for _, b := range rawOptions(opts, num, protowire.BytesType) {
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			continue
+		}
+		values = append(values, string(v))
+	}
+	return values
+}
+
+// rawOption returns the encoded value of the last option with the given number.
+func rawOption(opts proto.Message, num protowire.Number, typ protowire.Type) ([]byte, bool) {
+	values := rawOptions(opts, num, typ)
+	if len(values) == 0 {
+		return nil, false
+	}
+	return values[len(values)-1], true
+}
+
+// rawOptions returns the encoded values of the options with the given number, in order.
+// Options are read from their wire format, this way both extensions which were
+// resolved and the ones kept as unknown fields are found.
+func rawOptions(opts proto.Message, num protowire.Number, typ protowire.Type) [][]byte {
+	if opts == nil || !opts.ProtoReflect().IsValid() {
+		return nil
+	}
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(opts)
+	if err != nil {
+		return nil
+	}
+
+	var values [][]byte
+	for len(b) > 0 {
+		n, t, tagLen := protowire.ConsumeTag(b)
+		if tagLen < 0 {
+			return nil
+		}
+		b = b[tagLen:]
+		valueLen := protowire.ConsumeFieldValue(n, t, b)
+		if valueLen < 0 {
+			return nil
+		}
+		if n == num && t == typ {
+			values = append(values, b[:valueLen])
+		}
+		b = b[valueLen:]
+	}
+
+	return values
+}
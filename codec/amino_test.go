@@ -0,0 +1,170 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// withStringOption returns options containing the string option as an unknown field,
+// like it happens for custom options fetched from chains.
+func withStringOption(opts proto.Message, num protowire.Number, v string) {
+	b := protowire.AppendTag(nil, num, protowire.BytesType)
+	b = protowire.AppendString(b, v)
+	opts.ProtoReflect().SetUnknown(append(opts.ProtoReflect().GetUnknown(), b...))
+}
+
+func withBoolOption(opts proto.Message, num protowire.Number, v bool) {
+	b := protowire.AppendTag(nil, num, protowire.VarintType)
+	b = protowire.AppendVarint(b, protowire.EncodeBool(v))
+	opts.ProtoReflect().SetUnknown(append(opts.ProtoReflect().GetUnknown(), b...))
+}
+
+func aminoTestCodec(t *testing.T) *Codec {
+	coinsOpts := new(descriptorpb.FieldOptions)
+	withBoolOption(coinsOpts, gogoNullableOption, false)
+	withStringOption(coinsOpts, aminoEncodingOption, "legacy_coins")
+
+	timeOpts := new(descriptorpb.FieldOptions)
+	withBoolOption(timeOpts, gogoNullableOption, false)
+
+	sendOpts := new(descriptorpb.MessageOptions)
+	withStringOption(sendOpts, aminoNameOption, "cosmos-sdk/MsgSend")
+
+	execOpts := new(descriptorpb.MessageOptions)
+	withStringOption(execOpts, aminoNameOption, "cosmos-sdk/MsgExec")
+
+	memoOpts := new(descriptorpb.FieldOptions)
+	withStringOption(memoOpts, aminoFieldNameOption, "note")
+	withBoolOption(memoOpts, aminoDontOmitEmptyOption, true)
+
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, repeated bool, opts *descriptorpb.FieldOptions) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    label.Enum(),
+			Type:     typ.Enum(),
+			JsonName: proto.String(name),
+			Options:  opts,
+		}
+		if typeName != "" {
+			fd.TypeName = proto.String(typeName)
+		}
+		return fd
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/v1/tx.proto"),
+		Package:    proto.String("test.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/any.proto", "google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Coin"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("denom", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", false, nil),
+					field("amount", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", false, nil),
+				},
+			},
+			{
+				Name: proto.String("MsgSend"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("from_address", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", false, nil),
+					field("to_address", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", false, nil),
+					field("amount", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".test.v1.Coin", true, coinsOpts),
+					field("sequence", 4, descriptorpb.FieldDescriptorProto_TYPE_UINT64, "", false, nil),
+					field("time", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp", false, timeOpts),
+					field("memo", 6, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", false, memoOpts),
+					field("flag", 7, descriptorpb.FieldDescriptorProto_TYPE_BOOL, "", false, nil),
+				},
+				Options: sendOpts,
+			},
+			{
+				Name: proto.String("MsgExec"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("grantee", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", false, nil),
+					field("msgs", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Any", true, nil),
+				},
+				Options: execOpts,
+			},
+		},
+	}
+
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(anypb.File_google_protobuf_any_proto),
+		protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+		file,
+	}}
+
+	return NewCodec(NewCacheProtoFileRegistry(set))
+}
+
+func newAminoMessage(t *testing.T, cdc *Codec, name protoreflect.FullName) protoreflect.Message {
+	mt, err := cdc.Registry.FindMessageByName(name)
+	require.NoError(t, err)
+	return mt.New()
+}
+
+func TestCodec_AminoJSON(t *testing.T) {
+	cdc := aminoTestCodec(t)
+
+	send := newAminoMessage(t, cdc, "test.v1.MsgSend")
+	fields := send.Descriptor().Fields()
+	send.Set(fields.ByName("from_address"), protoreflect.ValueOfString("cosmos1from"))
+	send.Set(fields.ByName("sequence"), protoreflect.ValueOfUint64(18446744073709551615))
+	ts := send.Mutable(fields.ByName("time")).Message()
+	ts.Set(ts.Descriptor().Fields().ByName("seconds"), protoreflect.ValueOfInt64(1640995200))
+
+	b, err := cdc.MarshalAminoJSON(send.Interface())
+	require.NoError(t, err)
+	require.Equal(t, `{"type":"cosmos-sdk/MsgSend","value":{"from_address":"cosmos1from","sequence":"18446744073709551615","time":"2022-01-01T00:00:00Z","note":""}}`, string(b))
+
+	// round trip
+	decoded := newAminoMessage(t, cdc, "test.v1.MsgSend")
+	require.NoError(t, cdc.UnmarshalAminoJSON(b, decoded.Interface()))
+	require.True(t, proto.Equal(send.Interface(), decoded.Interface()))
+
+	// any values are wrapped with their amino name
+	sendAny, err := cdc.NewAny(send.Interface())
+	require.NoError(t, err)
+	exec := newAminoMessage(t, cdc, "test.v1.MsgExec")
+	execFields := exec.Descriptor().Fields()
+	exec.Set(execFields.ByName("grantee"), protoreflect.ValueOfString("cosmos1grantee"))
+	exec.Mutable(execFields.ByName("msgs")).List().Append(protoreflect.ValueOfMessage(sendAny.ProtoReflect()))
+
+	b, err = cdc.MarshalAminoJSON(exec.Interface())
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"msgs":[{"type":"cosmos-sdk/MsgSend","value":{"from_address":"cosmos1from"`)
+
+	decodedExec := newAminoMessage(t, cdc, "test.v1.MsgExec")
+	require.NoError(t, cdc.UnmarshalAminoJSON(b, decodedExec.Interface()))
+	decodedAny, err := toAny(decodedExec.Get(execFields.ByName("msgs")).List().Get(0).Message())
+	require.NoError(t, err)
+	require.Equal(t, "/test.v1.MsgSend", decodedAny.TypeUrl)
+	require.Equal(t, sendAny.Value, decodedAny.Value)
+
+	// unset any values are encoded as null
+	emptyExec := newAminoMessage(t, cdc, "test.v1.MsgExec")
+	emptyExec.Mutable(execFields.ByName("msgs")).List().Append(protoreflect.ValueOfMessage(new(anypb.Any).ProtoReflect()))
+	b, err = cdc.MarshalAminoJSON(emptyExec.Interface())
+	require.NoError(t, err)
+	require.Equal(t, `{"type":"cosmos-sdk/MsgExec","value":{"msgs":[null]}}`, string(b))
+	decodedExec = newAminoMessage(t, cdc, "test.v1.MsgExec")
+	require.NoError(t, cdc.UnmarshalAminoJSON(b, decodedExec.Interface()))
+	require.Equal(t, 1, decodedExec.Get(execFields.ByName("msgs")).List().Len())
+
+	sorted, err := SortJSON([]byte(`{"b":"1","a":{"d":2,"c":1}}`))
+	require.NoError(t, err)
+	require.Equal(t, `{"a":{"c":1,"d":2},"b":"1"}`, string(sorted))
+}
@@ -23,6 +23,7 @@ type Codec struct {
 	unmarshal     proto.UnmarshalOptions
 	jsonMarshal   protojson.MarshalOptions
 	jsonUnmarshal protojson.UnmarshalOptions
+	unknown       unknownFieldsOptions
 }
 
 func (c *Codec) NewAny(m proto.Message) (*anypb.Any, error) {
@@ -42,6 +43,9 @@ func (c *Codec) MarshalProto(m proto.Message) ([]byte, error) {
 }
 
 func (c *Codec) UnmarshalProto(b []byte, m proto.Message) error {
+	if c.unknown.enabled() {
+		return c.unmarshalProtoChecked(b, m)
+	}
 	return c.unmarshal.Unmarshal(b, m)
 }
 
@@ -50,6 +54,9 @@ func (c *Codec) MarshalProtoJSON(m proto.Message) ([]byte, error) {
 }
 
 func (c *Codec) UnmarshalProtoJSON(b []byte, m proto.Message) error {
+	if c.unknown.enabled() {
+		return c.unmarshalProtoJSONChecked(b, m)
+	}
 	return c.jsonUnmarshal.Unmarshal(b, m)
 }
 
@@ -65,13 +72,16 @@ func (c *Codec) ProtoOptions() ProtoOptions {
 func (c *Codec) GRPCCodec() encoding.Codec {
 	return &grpcCodec{
 		m: c.marshal,
-		u: c.unmarshal,
+		u: c.UnmarshalProto,
 	}
 }
 
-func NewCodec(remote ProtoFileRegistry) *Codec {
+// NewCodec returns a Codec resolving types through a Registry backed by
+// the provided remote. By default binary marshalling is deterministic and
+// JSON is compact, uses lowerCamelCase names and omits unpopulated fields.
+func NewCodec(remote ProtoFileRegistry, opts ...Option) *Codec {
 	registry := NewRegistry(remote)
-	return &Codec{
+	cdc := &Codec{
 		Registry: registry,
 		marshal: proto.MarshalOptions{
 			Deterministic: true,
@@ -93,14 +103,20 @@ func NewCodec(remote ProtoFileRegistry) *Codec {
 			DiscardUnknown: false,
 			Resolver:       registry,
 		},
+		unknown: unknownFieldsOptions{
+			lastRefresh: new(refreshState),
+		},
 	}
+	cdc.apply(opts)
+
+	return cdc
 }
 
 var _ encoding.Codec = (*grpcCodec)(nil)
 
 type grpcCodec struct {
 	m proto.MarshalOptions
-	u proto.UnmarshalOptions
+	u func(b []byte, m proto.Message) error
 }
 
 func (g *grpcCodec) Marshal(v interface{}) ([]byte, error) {
@@ -118,7 +134,7 @@ func (g *grpcCodec) Unmarshal(data []byte, v interface{}) error {
 		return fmt.Errorf("dynamic cosmos grpcCodec client can only work with proto.Message")
 	}
 
-	return g.u.Unmarshal(data, msg)
+	return g.u(data, msg)
 }
 
 func (g *grpcCodec) Name() string {
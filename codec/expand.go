@@ -0,0 +1,157 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DefaultMaxAnyDepth is the Any nesting depth used by Expand when none is provided.
+const DefaultMaxAnyDepth = 32
+
+// ErrMaxAnyDepth is returned when expanding messages whose Any values are nested too deep.
+var ErrMaxAnyDepth = errors.New("maximum Any nesting depth exceeded")
+
+// Node is a message whose nested Any values were unpacked.
+type Node struct {
+	// Path is the path of the Any containing the message,
+	// relative to the root message, empty for the root.
+	Path string
+	// TypeURL is the type URL of the Any containing the message, empty for the root.
+	TypeURL string
+	// Message is the unpacked message, it can be modified and packed back using Codec.Pack.
+	Message protoreflect.Message
+	// Children are the messages unpacked from the Any values contained in Message.
+	Children []*Node
+
+	any protoreflect.Message
+}
+
+// Walk calls fn for the node and all its descendants, parents first.
+func (n *Node) Walk(fn func(n *Node) error) error {
+	err := fn(n)
+	if err != nil {
+		return err
+	}
+	for _, child := range n.Children {
+		err = child.Walk(fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Expand resolves through the Registry and unpacks every Any contained in the message,
+// recursively, up to maxDepth nested Any values. If maxDepth is zero DefaultMaxAnyDepth
+// is used. Empty Any values are skipped.
+func (c *Codec) Expand(m proto.Message, maxDepth int) (*Node, error) {
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxAnyDepth
+	}
+
+	root := &Node{Message: m.ProtoReflect()}
+	err := c.expand(root, 0, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func (c *Codec) expand(n *Node, depth, maxDepth int) error {
+	return walkMessage(n.Message, n.Path, func(path string, msg protoreflect.Message) error {
+		if msg.Descriptor().FullName() != anyFullName {
+			return nil
+		}
+		anyMsg, err := toAny(msg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if anyMsg.TypeUrl == "" {
+			return nil
+		}
+		if depth >= maxDepth {
+			return fmt.Errorf("%s: %w", path, ErrMaxAnyDepth)
+		}
+
+		mt, err := c.Registry.FindMessageByURL(anyMsg.TypeUrl)
+		if err != nil {
+			return fmt.Errorf("%s: unable to resolve %s: %w", path, anyMsg.TypeUrl, err)
+		}
+		value := mt.New()
+		err = c.unmarshal.Unmarshal(anyMsg.Value, value.Interface())
+		if err != nil {
+			return fmt.Errorf("%s: unable to unpack %s: %w", path, anyMsg.TypeUrl, err)
+		}
+
+		child := &Node{
+			Path:    path,
+			TypeURL: anyMsg.TypeUrl,
+			Message: value,
+			any:     msg,
+		}
+		n.Children = append(n.Children, child)
+		return c.expand(child, depth+1, maxDepth)
+	})
+}
+
+// Pack marshals back the messages of the expanded tree into their Any values,
+// deepest first, and returns the root message.
+func (c *Codec) Pack(n *Node) (proto.Message, error) {
+	for _, child := range n.Children {
+		_, err := c.Pack(child)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := c.marshal.Marshal(child.Message.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("%s: unable to pack %s: %w", child.Path, child.TypeURL, err)
+		}
+		fields := child.any.Descriptor().Fields()
+		child.any.Set(fields.ByName("type_url"), protoreflect.ValueOfString(child.TypeURL))
+		child.any.Set(fields.ByName("value"), protoreflect.ValueOfBytes(b))
+	}
+
+	return n.Message.Interface(), nil
+}
+
+// walkMessage calls fn for the message and all the messages it contains,
+// parents first, along with their path. The fields of Any values are not visited.
+func walkMessage(msg protoreflect.Message, path string, fn func(path string, msg protoreflect.Message) error) error {
+	err := fn(path, msg)
+	if err != nil {
+		return err
+	}
+	if msg.Descriptor().FullName() == anyFullName {
+		return nil
+	}
+
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Message() == nil {
+			return true
+		}
+		fieldPath := joinPath(path, string(fd.Name()))
+		switch {
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len() && err == nil; i++ {
+				err = walkMessage(list.Get(i).Message(), fmt.Sprintf("%s[%d]", fieldPath, i), fn)
+			}
+		case fd.IsMap():
+			if fd.MapValue().Message() == nil {
+				return true
+			}
+			v.Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+				err = walkMessage(value.Message(), fmt.Sprintf("%s[%s]", fieldPath, key.String()), fn)
+				return err == nil
+			})
+		default:
+			err = walkMessage(v.Message(), fieldPath, fn)
+		}
+		return err == nil
+	})
+	return err
+}
@@ -0,0 +1,56 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestCodec_Expand(t *testing.T) {
+	cdc := aminoTestCodec(t)
+
+	send := newAminoMessage(t, cdc, "test.v1.MsgSend")
+	send.Set(send.Descriptor().Fields().ByName("from_address"), protoreflect.ValueOfString("cosmos1from"))
+
+	// exec(exec(send))
+	wrap := func(msg protoreflect.Message) protoreflect.Message {
+		anyMsg, err := cdc.NewAny(msg.Interface())
+		require.NoError(t, err)
+		exec := newAminoMessage(t, cdc, "test.v1.MsgExec")
+		exec.Mutable(exec.Descriptor().Fields().ByName("msgs")).List().Append(protoreflect.ValueOfMessage(anyMsg.ProtoReflect()))
+		return exec
+	}
+	exec := wrap(wrap(send))
+
+	_, err := cdc.Expand(exec.Interface(), 1)
+	require.ErrorIs(t, err, ErrMaxAnyDepth)
+
+	root, err := cdc.Expand(exec.Interface(), 0)
+	require.NoError(t, err)
+
+	var paths []string
+	require.NoError(t, root.Walk(func(n *Node) error {
+		paths = append(paths, n.Path+"|"+n.TypeURL)
+		return nil
+	}))
+	require.Equal(t, []string{"|", "msgs[0]|/test.v1.MsgExec", "msgs[0].msgs[0]|/test.v1.MsgSend"}, paths)
+
+	// modify the innermost message and pack it back
+	inner := root.Children[0].Children[0].Message
+	require.Equal(t, "cosmos1from", inner.Get(inner.Descriptor().Fields().ByName("from_address")).String())
+	inner.Set(inner.Descriptor().Fields().ByName("from_address"), protoreflect.ValueOfString("cosmos1changed"))
+
+	packed, err := cdc.Pack(root)
+	require.NoError(t, err)
+
+	b, err := cdc.MarshalProto(packed)
+	require.NoError(t, err)
+	decoded := newAminoMessage(t, cdc, "test.v1.MsgExec")
+	require.NoError(t, cdc.UnmarshalProto(b, decoded.Interface()))
+
+	root, err = cdc.Expand(decoded.Interface(), 0)
+	require.NoError(t, err)
+	inner = root.Children[0].Children[0].Message
+	require.Equal(t, "cosmos1changed", inner.Get(inner.Descriptor().Fields().ByName("from_address")).String())
+}
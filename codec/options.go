@@ -0,0 +1,84 @@
+package codec
+
+// Option configures the encoding options of a Codec.
+type Option func(c *Codec)
+
+// WithDeterministic sets if binary marshalling is deterministic, it is by default.
+func WithDeterministic(deterministic bool) Option {
+	return func(c *Codec) {
+		c.marshal.Deterministic = deterministic
+	}
+}
+
+// WithDiscardUnknown sets if unknown fields are dropped when unmarshalling
+// both binary and JSON. By default unknown binary fields are kept and unknown
+// JSON fields make unmarshalling fail.
+func WithDiscardUnknown(discard bool) Option {
+	return func(c *Codec) {
+		c.unmarshal.DiscardUnknown = discard
+		c.jsonUnmarshal.DiscardUnknown = discard
+	}
+}
+
+// WithAllowPartial sets if messages missing required fields
+// can be marshalled and unmarshalled.
+func WithAllowPartial(allow bool) Option {
+	return func(c *Codec) {
+		c.marshal.AllowPartial = allow
+		c.unmarshal.AllowPartial = allow
+		c.jsonMarshal.AllowPartial = allow
+		c.jsonUnmarshal.AllowPartial = allow
+	}
+}
+
+// WithProtoNames sets if JSON uses the protobuf field names
+// instead of the lowerCamelCase JSON names.
+func WithProtoNames(protoNames bool) Option {
+	return func(c *Codec) {
+		c.jsonMarshal.UseProtoNames = protoNames
+	}
+}
+
+// WithEnumNumbers sets if JSON encodes enums as numbers instead of names.
+func WithEnumNumbers(numbers bool) Option {
+	return func(c *Codec) {
+		c.jsonMarshal.UseEnumNumbers = numbers
+	}
+}
+
+// WithEmitUnpopulated sets if JSON includes fields which are not populated.
+func WithEmitUnpopulated(emit bool) Option {
+	return func(c *Codec) {
+		c.jsonMarshal.EmitUnpopulated = emit
+	}
+}
+
+// WithIndent makes JSON multiline using the provided indentation,
+// an empty indent makes JSON compact again.
+func WithIndent(indent string) Option {
+	return func(c *Codec) {
+		c.jsonMarshal.Multiline = indent != ""
+		c.jsonMarshal.Indent = indent
+	}
+}
+
+// With returns a copy of the Codec with the options applied,
+// sharing the same Registry. It allows to override the options
+// for single calls:
+//
+//	b, err := cdc.With(codec.WithProtoNames(true)).MarshalProtoJSON(m)
+func (c *Codec) With(opts ...Option) *Codec {
+	cp := *c
+	cp.apply(opts)
+	return &cp
+}
+
+func (c *Codec) apply(opts []Option) {
+	for _, opt := range opts {
+		opt(c)
+	}
+	// types must always be resolved through the registry
+	c.unmarshal.Resolver = c.Registry
+	c.jsonMarshal.Resolver = c.Registry
+	c.jsonUnmarshal.Resolver = c.Registry
+}
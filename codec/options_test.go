@@ -0,0 +1,36 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestCodec_With(t *testing.T) {
+	cdc := NewCodec(NewCacheProtoFileRegistry(&descriptorpb.FileDescriptorSet{}), WithProtoNames(true))
+	msg := &descriptorpb.FieldDescriptorProto{
+		JsonName: proto.String("x"),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+	}
+
+	b, err := cdc.MarshalProtoJSON(msg)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"label":"LABEL_REPEATED","json_name":"x"}`, string(b))
+
+	// overrides do not modify the original codec
+	b, err = cdc.With(WithProtoNames(false), WithEnumNumbers(true)).MarshalProtoJSON(msg)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"label":3,"jsonName":"x"}`, string(b))
+
+	b, err = cdc.MarshalProtoJSON(msg)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"label":"LABEL_REPEATED","json_name":"x"}`, string(b))
+
+	// unknown fields
+	err = cdc.UnmarshalProtoJSON([]byte(`{"unknown":1}`), new(descriptorpb.FieldDescriptorProto))
+	require.Error(t, err)
+	err = cdc.With(WithDiscardUnknown(true)).UnmarshalProtoJSON([]byte(`{"unknown":1}`), new(descriptorpb.FieldDescriptorProto))
+	require.NoError(t, err)
+}
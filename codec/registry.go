@@ -2,6 +2,7 @@ package codec
 
 import (
 	"errors"
+	"sync"
 
 	"github.com/fdymylja/dynamic-cosmos/protoutil"
 	"google.golang.org/protobuf/reflect/protodesc"
@@ -13,9 +14,8 @@ import (
 
 func NewRegistry(remote ProtoFileRegistry) *Registry {
 	return &Registry{
-		remote:    remote,
-		prefFiles: new(protoregistry.Files),
-		prefTypes: new(protoregistry.Types),
+		remote: remote,
+		state:  newRegistryState(remote),
 	}
 }
 
@@ -31,31 +31,27 @@ type ProtoFileRegistry interface {
 	Close() error
 }
 
+// Registry resolves descriptors and types from the remote, caching them.
+// It's safe for concurrent use: lookups of cached descriptors share a read
+// lock, while resolving from the remote and Refresh take the write lock.
 type Registry struct {
-	// TODO(fdymylja): probably needs to be protected by a mutex.
 	remote ProtoFileRegistry
 
-	prefFiles *protoregistry.Files
-	prefTypes *protoregistry.Types
+	mu    sync.RWMutex
+	state *registryState
 }
 
 func (r *Registry) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
-	// try in types
-	xt, err := r.prefTypes.FindExtensionByName(field)
-	if err == nil {
-		return xt, nil
-	}
+	r.mu.RLock()
+	xt, err := r.state.types.FindExtensionByName(field)
+	r.mu.RUnlock()
 	if !errors.Is(err, protoregistry.NotFound) {
-		return nil, err
-	}
-	// not found try in files
-	xd, err := r.FindDescriptorByName(field)
-	if err != nil {
-		return nil, err
+		return xt, err
 	}
 
-	xt = dynamicpb.NewExtensionType(xd.(protoreflect.ExtensionDescriptor))
-	return xt, r.prefTypes.RegisterExtension(xt)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.FindExtensionByName(field)
 }
 
 func (r *Registry) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
@@ -64,45 +60,153 @@ func (r *Registry) FindExtensionByNumber(message protoreflect.FullName, field pr
 }
 
 func (r *Registry) FindMessageByName(message protoreflect.FullName) (protoreflect.MessageType, error) {
-	mt, err := r.prefTypes.FindMessageByName(message)
+	r.mu.RLock()
+	mt, err := r.state.types.FindMessageByName(message)
+	r.mu.RUnlock()
+	if !errors.Is(err, protoregistry.NotFound) {
+		return mt, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.FindMessageByName(message)
+}
+
+func (r *Registry) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	r.mu.RLock()
+	mt, err := r.state.types.FindMessageByURL(url)
+	r.mu.RUnlock()
+	if !errors.Is(err, protoregistry.NotFound) {
+		return mt, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.FindMessageByName(protoutil.FullNameFromURL(url))
+}
+
+func (r *Registry) FindFileByPath(s string) (protoreflect.FileDescriptor, error) {
+	r.mu.RLock()
+	fd, err := r.state.files.FindFileByPath(s)
+	r.mu.RUnlock()
+	if !errors.Is(err, protoregistry.NotFound) {
+		return fd, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.FindFileByPath(s)
+}
+
+func (r *Registry) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	r.mu.RLock()
+	desc, err := r.state.files.FindDescriptorByName(name)
+	r.mu.RUnlock()
+	if !errors.Is(err, protoregistry.NotFound) {
+		return desc, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.FindDescriptorByName(name)
+}
+
+// rangeFiles iterates over the files resolved so far, while holding the read lock.
+func (r *Registry) rangeFiles(f func(protoreflect.FileDescriptor) bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.state.files.RangeFiles(f)
+}
+
+func (r *Registry) Save() (*descriptorpb.FileDescriptorSet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set := &descriptorpb.FileDescriptorSet{File: make([]*descriptorpb.FileDescriptorProto, 0, r.state.files.NumFiles())}
+	r.state.files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+		return true
+	})
+
+	return set, nil
+}
+
+// Refresh drops the descriptors and types resolved so far, so that they are
+// fetched again from the remote the next time they are needed. Types resolved
+// before the refresh keep working with their old descriptors.
+func (r *Registry) Refresh() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = newRegistryState(r.remote)
+}
+
+func (r *Registry) Remote() ProtoFileRegistry {
+	return r.remote
+}
+
+// registryState holds the descriptors and types resolved from the remote. It's not safe
+// for concurrent use, the Registry calls it while holding the write lock, and it's the
+// resolver of the files it builds so that dependencies are resolved without locking again.
+type registryState struct {
+	remote ProtoFileRegistry
+
+	files *protoregistry.Files
+	types *protoregistry.Types
+	// aminoFields caches aminoFieldInfo by protoreflect.FieldDescriptor,
+	// it's safe for concurrent use and dropped with the state on Refresh.
+	aminoFields *sync.Map
+}
+
+var _ protodesc.Resolver = (*registryState)(nil)
+
+func newRegistryState(remote ProtoFileRegistry) *registryState {
+	return &registryState{
+		remote:      remote,
+		files:       new(protoregistry.Files),
+		types:       new(protoregistry.Types),
+		aminoFields: new(sync.Map),
+	}
+}
+
+func (s *registryState) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	// try in types
+	xt, err := s.types.FindExtensionByName(field)
 	if err == nil {
-		return mt, nil
+		return xt, nil
 	}
 	if !errors.Is(err, protoregistry.NotFound) {
 		return nil, err
 	}
-
-	md, err := r.FindDescriptorByName(message)
+	// not found try in files
+	xd, err := s.FindDescriptorByName(field)
 	if err != nil {
 		return nil, err
 	}
 
-	mt = dynamicpb.NewMessageType(md.(protoreflect.MessageDescriptor))
-	return mt, r.prefTypes.RegisterMessage(mt)
+	xt = dynamicpb.NewExtensionType(xd.(protoreflect.ExtensionDescriptor))
+	return xt, s.types.RegisterExtension(xt)
 }
 
-func (r *Registry) FindMessageByURL(url string) (protoreflect.MessageType, error) {
-	mt, err := r.prefTypes.FindMessageByURL(url)
+func (s *registryState) FindMessageByName(message protoreflect.FullName) (protoreflect.MessageType, error) {
+	mt, err := s.types.FindMessageByName(message)
 	if err == nil {
-		return mt, err
+		return mt, nil
 	}
 	if !errors.Is(err, protoregistry.NotFound) {
 		return nil, err
 	}
 
-	message := protoutil.FullNameFromURL(url)
-
-	md, err := r.FindDescriptorByName(message)
+	md, err := s.FindDescriptorByName(message)
 	if err != nil {
 		return nil, err
 	}
 
 	mt = dynamicpb.NewMessageType(md.(protoreflect.MessageDescriptor))
-	return mt, r.prefTypes.RegisterMessage(mt)
+	return mt, s.types.RegisterMessage(mt)
 }
 
-func (r Registry) FindFileByPath(s string) (protoreflect.FileDescriptor, error) {
-	fd, err := r.prefFiles.FindFileByPath(s)
+func (s *registryState) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	fd, err := s.files.FindFileByPath(path)
 	if err == nil {
 		return fd, nil
 	}
@@ -110,25 +214,25 @@ func (r Registry) FindFileByPath(s string) (protoreflect.FileDescriptor, error)
 		return nil, err
 	}
 
-	dpb, err := r.remote.ProtoFileByPath(s)
+	dpb, err := s.remote.ProtoFileByPath(path)
 	if err != nil {
 		return nil, err
 	}
 
-	fd, err = protodesc.NewFile(dpb, r)
+	fd, err = protodesc.NewFile(dpb, s)
 	if err != nil {
 		return nil, err
 	}
 
-	err = r.prefFiles.RegisterFile(fd)
+	err = s.files.RegisterFile(fd)
 	if err != nil {
 		return nil, err
 	}
 	return fd, nil
 }
 
-func (r Registry) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
-	desc, err := r.prefFiles.FindDescriptorByName(name)
+func (s *registryState) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	desc, err := s.files.FindDescriptorByName(name)
 	if err == nil {
 		return desc, nil
 	}
@@ -136,34 +240,19 @@ func (r Registry) FindDescriptorByName(name protoreflect.FullName) (protoreflect
 		return nil, err
 	}
 
-	dpb, err := r.remote.ProtoFileContainingSymbol(name)
+	dpb, err := s.remote.ProtoFileContainingSymbol(name)
 	if err != nil {
 		return nil, err
 	}
-	fd, err := protodesc.NewFile(dpb, r)
+	fd, err := protodesc.NewFile(dpb, s)
 	if err != nil {
 		return nil, err
 	}
 
-	err = r.prefFiles.RegisterFile(fd)
+	err = s.files.RegisterFile(fd)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.prefFiles.FindDescriptorByName(name)
-}
-
-func (r *Registry) Save() (*descriptorpb.FileDescriptorSet, error) {
-	set := &descriptorpb.FileDescriptorSet{File: make([]*descriptorpb.FileDescriptorProto, 0, r.prefFiles.NumFiles())}
-	var err error
-	r.prefFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
-		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
-		return true
-	})
-
-	return set, err
-}
-
-func (r *Registry) Remote() ProtoFileRegistry {
-	return r.remote
+	return s.files.FindDescriptorByName(name)
 }
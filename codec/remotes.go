@@ -2,11 +2,14 @@ package codec
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"sync"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 	"google.golang.org/protobuf/proto"
@@ -193,34 +196,44 @@ func findNameInDescriptorProto(name, parent protoreflect.FullName, desc *descrip
 	return false
 }
 
-func NewGRPCReflectionProtoFileRegistry(grpcEndpoint string) (*GRPCReflectionProtoFileRegistry, error) {
-	conn, err := grpc.Dial(grpcEndpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// NewGRPCReflectionProtoFileRegistry dials the endpoint and resolves files using its
+// gRPC reflection service. The connection is insecure unless the provided options
+// set the transport credentials.
+func NewGRPCReflectionProtoFileRegistry(grpcEndpoint string, opts ...grpc.DialOption) (*GRPCReflectionProtoFileRegistry, error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	conn, err := grpc.Dial(grpcEndpoint, opts...)
 	if err != nil {
 		return nil, err
 	}
 
+	remote := NewGRPCReflectionProtoFileRegistryFromConn(conn)
+	remote.conn = conn
+	return remote, nil
+}
+
+// NewGRPCReflectionProtoFileRegistryFromConn returns a GRPCReflectionProtoFileRegistry
+// using an existing connection, which is not closed by Close.
+func NewGRPCReflectionProtoFileRegistryFromConn(conn grpc.ClientConnInterface) *GRPCReflectionProtoFileRegistry {
 	return &GRPCReflectionProtoFileRegistry{
 		rpb:    grpc_reflection_v1alpha.NewServerReflectionClient(conn),
-		once:   new(sync.Once),
+		mu:     new(sync.Mutex),
 		stream: nil,
-	}, nil
+	}
 }
 
 // GRPCReflectionProtoFileRegistry is a ProtoFileRegistry
 // which uses grpc reflection to resolve files.
 type GRPCReflectionProtoFileRegistry struct {
-	rpb    grpc_reflection_v1alpha.ServerReflectionClient
-	once   *sync.Once
+	rpb grpc_reflection_v1alpha.ServerReflectionClient
+	// mu guards the stream, which serves one request at a time.
+	mu     *sync.Mutex
 	stream grpc_reflection_v1alpha.ServerReflection_ServerReflectionInfoClient
+	// conn is set only if the connection is owned by the registry.
+	conn *grpc.ClientConn
 }
 
 func (g *GRPCReflectionProtoFileRegistry) ProtoFileByPath(path string) (*descriptorpb.FileDescriptorProto, error) {
-	err := g.init()
-	if err != nil {
-		return nil, err
-	}
-
-	err = g.stream.Send(&grpc_reflection_v1alpha.ServerReflectionRequest{
+	recv, err := g.request(&grpc_reflection_v1alpha.ServerReflectionRequest{
 		MessageRequest: &grpc_reflection_v1alpha.ServerReflectionRequest_FileByFilename{
 			FileByFilename: path,
 		}})
@@ -228,61 +241,86 @@ func (g *GRPCReflectionProtoFileRegistry) ProtoFileByPath(path string) (*descrip
 		return nil, err
 	}
 
-	recv, err := g.stream.Recv()
-	if err != nil {
-		return nil, err
-	}
-
-	resp := recv.MessageResponse.(*grpc_reflection_v1alpha.ServerReflectionResponse_FileDescriptorResponse)
-	fdRawBytes := resp.FileDescriptorResponse.FileDescriptorProto[0]
-	fdPb := &descriptorpb.FileDescriptorProto{}
-	err = proto.Unmarshal(fdRawBytes, fdPb)
-	if err != nil {
-		return nil, err
-	}
-
-	return fdPb, nil
+	return fileFromReflectionResponse(recv)
 }
 
 func (g *GRPCReflectionProtoFileRegistry) ProtoFileContainingSymbol(name protoreflect.FullName) (*descriptorpb.FileDescriptorProto, error) {
-	err := g.init()
-	if err != nil {
-		return nil, err
-	}
-
-	err = g.stream.Send(&grpc_reflection_v1alpha.ServerReflectionRequest{
+	recv, err := g.request(&grpc_reflection_v1alpha.ServerReflectionRequest{
 		MessageRequest: &grpc_reflection_v1alpha.ServerReflectionRequest_FileContainingSymbol{
 			FileContainingSymbol: string(name),
 		},
 	})
-
 	if err != nil {
 		return nil, err
 	}
 
-	recv, err := g.stream.Recv()
+	return fileFromReflectionResponse(recv)
+}
+
+// request sends the request on the reflection stream, which is opened on the first
+// request, and opened again by the next one after it fails.
+func (g *GRPCReflectionProtoFileRegistry) request(req *grpc_reflection_v1alpha.ServerReflectionRequest) (*grpc_reflection_v1alpha.ServerReflectionResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.stream == nil {
+		stream, err := g.rpb.ServerReflectionInfo(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		g.stream = stream
+	}
+
+	err := g.stream.Send(req)
 	if err != nil {
+		g.resetStream()
 		return nil, err
 	}
-
-	resp := recv.MessageResponse.(*grpc_reflection_v1alpha.ServerReflectionResponse_FileDescriptorResponse)
-	fdRawBytes := resp.FileDescriptorResponse.FileDescriptorProto[0]
-	fdPb := &descriptorpb.FileDescriptorProto{}
-	err = proto.Unmarshal(fdRawBytes, fdPb)
+	recv, err := g.stream.Recv()
 	if err != nil {
+		g.resetStream()
 		return nil, err
 	}
+	return recv, nil
+}
 
-	return fdPb, nil
+func (g *GRPCReflectionProtoFileRegistry) resetStream() {
+	_ = g.stream.CloseSend()
+	g.stream = nil
 }
 
-func (g *GRPCReflectionProtoFileRegistry) init() (err error) {
-	g.once.Do(func() {
-		g.stream, err = g.rpb.ServerReflectionInfo(context.Background())
-	})
-	return err
+func fileFromReflectionResponse(recv *grpc_reflection_v1alpha.ServerReflectionResponse) (*descriptorpb.FileDescriptorProto, error) {
+	switch resp := recv.MessageResponse.(type) {
+	case *grpc_reflection_v1alpha.ServerReflectionResponse_FileDescriptorResponse:
+		if len(resp.FileDescriptorResponse.FileDescriptorProto) == 0 {
+			return nil, fmt.Errorf("empty reflection response")
+		}
+		fdPb := &descriptorpb.FileDescriptorProto{}
+		err := proto.Unmarshal(resp.FileDescriptorResponse.FileDescriptorProto[0], fdPb)
+		if err != nil {
+			return nil, err
+		}
+		return fdPb, nil
+	case *grpc_reflection_v1alpha.ServerReflectionResponse_ErrorResponse:
+		return nil, status.Error(codes.Code(resp.ErrorResponse.ErrorCode), resp.ErrorResponse.ErrorMessage)
+	default:
+		return nil, fmt.Errorf("unexpected reflection response %T", resp)
+	}
 }
 
 func (g *GRPCReflectionProtoFileRegistry) Close() error {
-	return g.stream.CloseSend()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var err error
+	// the stream is opened only when the first file is requested
+	if g.stream != nil {
+		err = g.stream.CloseSend()
+	}
+	if g.conn != nil {
+		if connErr := g.conn.Close(); err == nil {
+			err = connErr
+		}
+	}
+	return err
 }
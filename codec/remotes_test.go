@@ -0,0 +1,83 @@
+package codec
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGRPCReflectionProtoFileRegistryFromConn(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+	reflection.Register(srv)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	remote := NewGRPCReflectionProtoFileRegistryFromConn(conn)
+	registry := NewRegistry(remote)
+
+	desc, err := registry.FindDescriptorByName("grpc.health.v1.Health")
+	require.NoError(t, err)
+	require.Equal(t, "grpc/health/v1/health.proto", desc.ParentFile().Path())
+
+	// closing the remote leaves the shared connection usable
+	require.NoError(t, remote.Close())
+	_, err = grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+}
+
+// failingReflectionClient fails to open the first stream.
+type failingReflectionClient struct {
+	grpc_reflection_v1alpha.ServerReflectionClient
+	failed bool
+}
+
+func (c *failingReflectionClient) ServerReflectionInfo(ctx context.Context, opts ...grpc.CallOption) (grpc_reflection_v1alpha.ServerReflection_ServerReflectionInfoClient, error) {
+	if !c.failed {
+		c.failed = true
+		return nil, errors.New("stream failure")
+	}
+	return c.ServerReflectionClient.ServerReflectionInfo(ctx, opts...)
+}
+
+func TestGRPCReflectionProtoFileRegistry_Reopen(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	reflection.Register(srv)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	remote := NewGRPCReflectionProtoFileRegistryFromConn(conn)
+	remote.rpb = &failingReflectionClient{ServerReflectionClient: remote.rpb}
+
+	// the failure is returned, and the stream is opened again by the next request
+	_, err = remote.ProtoFileContainingSymbol("grpc.reflection.v1alpha.ServerReflection")
+	require.ErrorContains(t, err, "stream failure")
+	fd, err := remote.ProtoFileContainingSymbol("grpc.reflection.v1alpha.ServerReflection")
+	require.NoError(t, err)
+	require.Equal(t, "reflection/grpc_reflection_v1alpha/reflection.proto", fd.GetName())
+	require.NoError(t, remote.Close())
+}
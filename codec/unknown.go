@@ -0,0 +1,392 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// UnknownFieldsMode defines how decoding handles fields which
+// are not known to the message descriptors.
+type UnknownFieldsMode int
+
+const (
+	// UnknownFieldsDefault keeps unknown binary fields and fails
+	// on unknown JSON fields, like protobuf does.
+	UnknownFieldsDefault UnknownFieldsMode = iota
+	// UnknownFieldsLenient keeps unknown binary fields and ignores unknown JSON fields.
+	UnknownFieldsLenient
+	// UnknownFieldsStrict fails decoding with an *UnknownFieldsError
+	// when unknown fields are found, both in binary and JSON.
+	UnknownFieldsStrict
+)
+
+// minRefreshInterval is the minimum time between two descriptor refreshes
+// triggered by unknown fields, as fields might be unknown to the remote too.
+const minRefreshInterval = time.Minute
+
+// UnknownField describes a field which is not known to the message descriptor.
+type UnknownField struct {
+	// Path is the path of the message containing the field,
+	// relative to the decoded message, empty for the message itself.
+	Path string
+	// Message is the name of the message containing the field.
+	Message protoreflect.FullName
+	// Number is the field number, it's only set for binary decoding.
+	Number protowire.Number
+	// Name is the JSON field name, it's only set for JSON decoding.
+	Name string
+}
+
+func (f UnknownField) String() string {
+	field := f.Name
+	if field == "" {
+		field = strconv.Itoa(int(f.Number))
+	}
+	if f.Path == "" {
+		return fmt.Sprintf("%s: unknown field %s", f.Message, field)
+	}
+	return fmt.Sprintf("%s (%s): unknown field %s", f.Path, f.Message, field)
+}
+
+// UnknownFieldsError is returned by decoding when using UnknownFieldsStrict,
+// and reported to the UnknownFieldsHandler.
+type UnknownFieldsError struct {
+	// Fields are the fields unknown to the decoded message.
+	Fields []UnknownField
+	// Refreshed is the message decoded again using the type resolved from the
+	// Registry, when it has different descriptors than the decoded message, see
+	// WithRefreshOnUnknownFields. Fields might be known to it, in which case
+	// the error is only reported to the UnknownFieldsHandler.
+	Refreshed proto.Message
+}
+
+func (e *UnknownFieldsError) Error() string {
+	reasons := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		reasons[i] = f.String()
+	}
+	return "unknown fields: " + strings.Join(reasons, "; ")
+}
+
+// UnknownFieldsHandler is called after decoding a message containing unknown fields.
+type UnknownFieldsHandler func(m proto.Message, unknown *UnknownFieldsError)
+
+// WithUnknownFields sets how decoding handles unknown fields.
+func WithUnknownFields(mode UnknownFieldsMode) Option {
+	return func(c *Codec) {
+		c.unknown.mode = mode
+	}
+}
+
+// WithUnknownFieldsHandler sets a handler which is notified of the
+// unknown fields found in decoded messages.
+func WithUnknownFieldsHandler(handler UnknownFieldsHandler) Option {
+	return func(c *Codec) {
+		c.unknown.handler = handler
+	}
+}
+
+// WithRefreshOnUnknownFields makes decoding refresh the Registry descriptors
+// from the remote when unknown fields are found, which usually means the chain
+// was upgraded after the descriptors were fetched. Any values are then resolved
+// using the new descriptors, while the decoded message keeps its own: if its type
+// changed, the message decoded again using the new type is reported as
+// UnknownFieldsError.Refreshed, and UnknownFieldsStrict decoding succeeds
+// if the new type knows every field.
+func WithRefreshOnUnknownFields(refresh bool) Option {
+	return func(c *Codec) {
+		c.unknown.refresh = refresh
+	}
+}
+
+type unknownFieldsOptions struct {
+	mode    UnknownFieldsMode
+	handler UnknownFieldsHandler
+	refresh bool
+	// lastRefresh is shared between codecs derived using Codec.With.
+	lastRefresh *refreshState
+}
+
+type refreshState struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+func (o unknownFieldsOptions) enabled() bool {
+	return o.mode != UnknownFieldsDefault || o.handler != nil || o.refresh
+}
+
+// tryRefresh refreshes the registry, unless it was refreshed recently.
+func (c *Codec) tryRefresh() bool {
+	state := c.unknown.lastRefresh
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if time.Since(state.last) < minRefreshInterval {
+		return false
+	}
+	state.last = time.Now()
+	c.Registry.Refresh()
+	return true
+}
+
+// reportUnknown reports the unknown fields of m, known reports if the
+// refreshed message knows every field, so that strict decoding succeeds.
+func (c *Codec) reportUnknown(m proto.Message, fields []UnknownField, refreshed proto.Message, known bool) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	unknown := &UnknownFieldsError{Fields: fields, Refreshed: refreshed}
+	if c.unknown.handler != nil {
+		c.unknown.handler(m, unknown)
+	}
+	if c.unknown.mode == UnknownFieldsStrict && !known {
+		return unknown
+	}
+	return nil
+}
+
+// refreshedMessage decodes b into a message of the type of m resolved from the Registry,
+// it returns nil if the type has the same descriptor as m, or if decoding fails.
+func (c *Codec) refreshedMessage(b []byte, m proto.Message, unmarshal func([]byte, proto.Message) error) proto.Message {
+	md := m.ProtoReflect().Descriptor()
+	mt, err := c.Registry.FindMessageByName(md.FullName())
+	if err != nil || mt.Descriptor() == md {
+		return nil
+	}
+	refreshed := mt.New().Interface()
+	if err = unmarshal(b, refreshed); err != nil {
+		return nil
+	}
+	return refreshed
+}
+
+func (c *Codec) unmarshalProtoChecked(b []byte, m proto.Message) error {
+	err := c.unmarshal.Unmarshal(b, m)
+	if err != nil {
+		return err
+	}
+
+	unknown := c.UnknownFields(m)
+	var refreshed proto.Message
+	if len(unknown) != 0 && c.unknown.refresh {
+		// the Any values of m are resolved again using the refreshed descriptors
+		if c.tryRefresh() {
+			unknown = c.UnknownFields(m)
+		}
+		// the registry might have been refreshed already by another decoding
+		if len(unknown) != 0 {
+			refreshed = c.refreshedMessage(b, m, c.unmarshal.Unmarshal)
+		}
+	}
+
+	known := refreshed != nil && len(c.UnknownFields(refreshed)) == 0
+	return c.reportUnknown(m, unknown, refreshed, known)
+}
+
+func (c *Codec) unmarshalProtoJSONChecked(b []byte, m proto.Message) error {
+	unknown, err := c.UnknownJSONFields(b, m)
+	if err != nil {
+		// let protojson report a meaningful error
+		return c.jsonUnmarshal.Unmarshal(b, m)
+	}
+
+	// unknown fields are dropped unless using the default mode, where protojson fails on them
+	opts := c.jsonUnmarshal
+	if c.unknown.mode != UnknownFieldsDefault {
+		opts.DiscardUnknown = true
+	}
+
+	var (
+		refreshed proto.Message
+		known     bool
+	)
+	if len(unknown) != 0 && c.unknown.refresh {
+		if c.tryRefresh() {
+			unknown, err = c.UnknownJSONFields(b, m)
+			if err != nil {
+				return c.jsonUnmarshal.Unmarshal(b, m)
+			}
+		}
+		if len(unknown) != 0 {
+			refreshed = c.refreshedMessage(b, m, opts.Unmarshal)
+		}
+		if refreshed != nil {
+			refreshedUnknown, err := c.UnknownJSONFields(b, refreshed)
+			known = err == nil && len(refreshedUnknown) == 0
+		}
+	}
+
+	// m is decoded before reporting, so that the handler and strict mode callers get it populated
+	err = opts.Unmarshal(b, m)
+	if err != nil {
+		return err
+	}
+	return c.reportUnknown(m, unknown, refreshed, known)
+}
+
+// UnknownFields returns the unknown fields contained in the message and in
+// its nested messages. Any values are unpacked using the Registry, values
+// which can't be resolved are not inspected.
+func (c *Codec) UnknownFields(m proto.Message) []UnknownField {
+	var fields []UnknownField
+	c.unknownFields(m.ProtoReflect(), "", &fields)
+	return fields
+}
+
+func (c *Codec) unknownFields(msg protoreflect.Message, path string, fields *[]UnknownField) {
+	_ = walkMessage(msg, path, func(path string, msg protoreflect.Message) error {
+		b := msg.GetUnknown()
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			if n < 0 {
+				break
+			}
+			b = b[n:]
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				break
+			}
+			b = b[n:]
+			*fields = append(*fields, UnknownField{Path: path, Message: msg.Descriptor().FullName(), Number: num})
+		}
+
+		if msg.Descriptor().FullName() == anyFullName {
+			c.unknownFieldsAny(msg, path, fields)
+		}
+		return nil
+	})
+}
+
+func (c *Codec) unknownFieldsAny(msg protoreflect.Message, path string, fields *[]UnknownField) {
+	anyMsg, err := toAny(msg)
+	if err != nil || anyMsg.TypeUrl == "" {
+		return
+	}
+	mt, err := c.Registry.FindMessageByURL(anyMsg.TypeUrl)
+	if err != nil {
+		return
+	}
+	value := mt.New()
+	if err = c.unmarshal.Unmarshal(anyMsg.Value, value.Interface()); err != nil {
+		return
+	}
+	c.unknownFields(value, path, fields)
+}
+
+// UnknownJSONFields returns the fields contained in the JSON encoded message
+// which are not known to the descriptor of m, m itself is not modified.
+func (c *Codec) UnknownJSONFields(b []byte, m proto.Message) ([]UnknownField, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v interface{}
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []UnknownField
+	c.unknownJSONFields(v, m.ProtoReflect().Descriptor(), "", &fields)
+	return fields, nil
+}
+
+func (c *Codec) unknownJSONFields(v interface{}, md protoreflect.MessageDescriptor, path string, fields *[]UnknownField) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	switch {
+	case md.FullName() == anyFullName:
+		typeURL, _ := obj["@type"].(string)
+		mt, err := c.Registry.FindMessageByURL(typeURL)
+		if err != nil {
+			return
+		}
+		anyObj := make(map[string]interface{}, len(obj))
+		for k, v := range obj {
+			if k != "@type" {
+				anyObj[k] = v
+			}
+		}
+		if hasSpecialJSON(mt.Descriptor()) {
+			c.unknownJSONFields(anyObj["value"], mt.Descriptor(), path, fields)
+			return
+		}
+		c.unknownJSONFields(anyObj, mt.Descriptor(), path, fields)
+		return
+	case hasSpecialJSON(md):
+		return
+	}
+
+	fds := md.Fields()
+	for name, value := range obj {
+		fd := fds.ByJSONName(name)
+		if fd == nil {
+			fd = fds.ByName(protoreflect.Name(name))
+		}
+		if fd == nil {
+			// extensions are resolved by protojson
+			if strings.HasPrefix(name, "[") {
+				continue
+			}
+			*fields = append(*fields, UnknownField{Path: path, Message: md.FullName(), Name: name})
+			continue
+		}
+		if fd.Message() == nil || value == nil {
+			continue
+		}
+
+		fieldPath := joinPath(path, string(fd.Name()))
+		switch {
+		case fd.IsList():
+			list, _ := value.([]interface{})
+			for i, elem := range list {
+				c.unknownJSONFields(elem, fd.Message(), fmt.Sprintf("%s[%d]", fieldPath, i), fields)
+			}
+		case fd.IsMap():
+			if fd.MapValue().Message() == nil {
+				continue
+			}
+			entries, _ := value.(map[string]interface{})
+			for k, elem := range entries {
+				c.unknownJSONFields(elem, fd.MapValue().Message(), fmt.Sprintf("%s[%s]", fieldPath, k), fields)
+			}
+		default:
+			c.unknownJSONFields(value, fd.Message(), fieldPath, fields)
+		}
+	}
+}
+
+// hasSpecialJSON reports if the message is a well known
+// type whose JSON representation is not an object of fields.
+func hasSpecialJSON(md protoreflect.MessageDescriptor) bool {
+	if md.ParentFile() == nil || md.ParentFile().Package() != "google.protobuf" {
+		return false
+	}
+	switch md.Name() {
+	case "Timestamp", "Duration", "FieldMask", "Struct", "Value", "ListValue",
+		"BoolValue", "Int32Value", "Int64Value", "UInt32Value", "UInt64Value",
+		"FloatValue", "DoubleValue", "StringValue", "BytesValue", "Empty":
+		return true
+	default:
+		return false
+	}
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
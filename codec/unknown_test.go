@@ -0,0 +1,165 @@
+package codec
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// upgradableRemote simulates a chain whose API changes after an upgrade.
+type upgradableRemote struct {
+	ProtoFileRegistry
+}
+
+func unknownTestSet(upgraded bool) *descriptorpb.FileDescriptorSet {
+	coinFields := []*descriptorpb.FieldDescriptorProto{
+		{Name: proto.String("denom"), JsonName: proto.String("denom"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()},
+	}
+	if upgraded {
+		coinFields = append(coinFields, &descriptorpb.FieldDescriptorProto{Name: proto.String("amount"), JsonName: proto.String("amount"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()})
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/v1/coin.proto"),
+		Package:    proto.String("test.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/any.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Coin"), Field: coinFields},
+			{Name: proto.String("Wrapper"), Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("msg"), JsonName: proto.String("msg"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(), TypeName: proto.String(".google.protobuf.Any")},
+			}},
+		},
+	}
+
+	return &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(anypb.File_google_protobuf_any_proto),
+		file,
+	}}
+}
+
+func newTestMessage(t *testing.T, cdc *Codec, name protoreflect.FullName) protoreflect.Message {
+	mt, err := cdc.Registry.FindMessageByName(name)
+	require.NoError(t, err)
+	return mt.New()
+}
+
+func TestCodec_UnknownFields(t *testing.T) {
+	upgradedCdc := NewCodec(NewCacheProtoFileRegistry(unknownTestSet(true)))
+	coin := newTestMessage(t, upgradedCdc, "test.v1.Coin")
+	coin.Set(coin.Descriptor().Fields().ByName("denom"), protoreflect.ValueOfString("atom"))
+	coin.Set(coin.Descriptor().Fields().ByName("amount"), protoreflect.ValueOfString("10"))
+	coinBytes, err := upgradedCdc.MarshalProto(coin.Interface())
+	require.NoError(t, err)
+
+	remote := &upgradableRemote{NewCacheProtoFileRegistry(unknownTestSet(false))}
+
+	t.Run("strict", func(t *testing.T) {
+		cdc := NewCodec(remote, WithUnknownFields(UnknownFieldsStrict))
+
+		err := cdc.UnmarshalProto(coinBytes, newTestMessage(t, cdc, "test.v1.Coin").Interface())
+		unknownErr := new(UnknownFieldsError)
+		require.ErrorAs(t, err, &unknownErr)
+		require.Equal(t, []UnknownField{{Message: "test.v1.Coin", Number: 2}}, unknownErr.Fields)
+
+		err = cdc.UnmarshalProtoJSON([]byte(`{"denom":"atom","amount":"10"}`), newTestMessage(t, cdc, "test.v1.Coin").Interface())
+		require.ErrorAs(t, err, &unknownErr)
+		require.Equal(t, []UnknownField{{Message: "test.v1.Coin", Name: "amount"}}, unknownErr.Fields)
+	})
+
+	t.Run("lenient", func(t *testing.T) {
+		var reported []UnknownField
+		cdc := NewCodec(remote, WithUnknownFields(UnknownFieldsLenient), WithUnknownFieldsHandler(func(_ proto.Message, unknown *UnknownFieldsError) {
+			reported = append(reported, unknown.Fields...)
+		}))
+
+		decoded := newTestMessage(t, cdc, "test.v1.Coin")
+		require.NoError(t, cdc.UnmarshalProtoJSON([]byte(`{"denom":"atom","amount":"10"}`), decoded.Interface()))
+		require.Equal(t, "atom", decoded.Get(decoded.Descriptor().Fields().ByName("denom")).String())
+		require.Equal(t, []UnknownField{{Message: "test.v1.Coin", Name: "amount"}}, reported)
+
+		// unknown fields inside any values are reported with their path
+		reported = nil
+		wrapper := newTestMessage(t, cdc, "test.v1.Wrapper")
+		require.NoError(t, cdc.UnmarshalProtoJSON([]byte(`{"msg":{"@type":"/test.v1.Coin","denom":"atom","amount":"10"}}`), wrapper.Interface()))
+		require.Equal(t, []UnknownField{{Path: "msg", Message: "test.v1.Coin", Name: "amount"}}, reported)
+
+		coinAny, err := upgradedCdc.NewAny(coin.Interface())
+		require.NoError(t, err)
+		wrapper = newTestMessage(t, upgradedCdc, "test.v1.Wrapper")
+		wrapper.Set(wrapper.Descriptor().Fields().ByName("msg"), protoreflect.ValueOfMessage(coinAny.ProtoReflect()))
+		wrapperBytes, err := upgradedCdc.MarshalProto(wrapper.Interface())
+		require.NoError(t, err)
+		reported = nil
+		require.NoError(t, cdc.UnmarshalProto(wrapperBytes, newTestMessage(t, cdc, "test.v1.Wrapper").Interface()))
+		require.Equal(t, []UnknownField{{Path: "msg", Message: "test.v1.Coin", Number: 2}}, reported)
+	})
+
+	t.Run("refresh", func(t *testing.T) {
+		remote := &upgradableRemote{NewCacheProtoFileRegistry(unknownTestSet(false))}
+		var reported *UnknownFieldsError
+		cdc := NewCodec(remote, WithUnknownFields(UnknownFieldsStrict), WithRefreshOnUnknownFields(true),
+			WithUnknownFieldsHandler(func(_ proto.Message, unknown *UnknownFieldsError) { reported = unknown }))
+
+		wrapper := newTestMessage(t, cdc, "test.v1.Wrapper")
+		// resolve the old coin type before the chain upgrade
+		_, err := cdc.Registry.FindMessageByName("test.v1.Coin")
+		require.NoError(t, err)
+
+		oldCoin := newTestMessage(t, cdc, "test.v1.Coin")
+
+		remote.ProtoFileRegistry = NewCacheProtoFileRegistry(unknownTestSet(true))
+		require.NoError(t, cdc.UnmarshalProtoJSON([]byte(`{"msg":{"@type":"/test.v1.Coin","denom":"atom","amount":"10"}}`), wrapper.Interface()))
+
+		// the old message can't know the new field, which is known to the refreshed one,
+		// so that strict decoding succeeds and the refreshed message is reported
+		for _, decode := range []func() error{
+			func() error { return cdc.UnmarshalProto(coinBytes, oldCoin.Interface()) },
+			func() error {
+				return cdc.UnmarshalProtoJSON([]byte(`{"denom":"atom","amount":"10"}`), oldCoin.Interface())
+			},
+		} {
+			reported = nil
+			require.NoError(t, decode())
+			require.NotNil(t, reported)
+			require.Len(t, reported.Fields, 1)
+			require.Equal(t, "atom", oldCoin.Get(oldCoin.Descriptor().Fields().ByName("denom")).String())
+			refreshed := reported.Refreshed.ProtoReflect()
+			require.Equal(t, "10", refreshed.Get(refreshed.Descriptor().Fields().ByName("amount")).String())
+		}
+	})
+	t.Run("concurrent refresh", func(t *testing.T) {
+		cdc := NewCodec(remote, WithUnknownFields(UnknownFieldsLenient))
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 8)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					cdc.Registry.Refresh()
+					mt, err := cdc.Registry.FindMessageByURL("/test.v1.Wrapper")
+					if err == nil {
+						err = cdc.UnmarshalProtoJSON([]byte(`{"msg":{"@type":"/test.v1.Coin","denom":"atom"}}`), mt.New().Interface())
+					}
+					if err != nil {
+						errs <- err
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+	})
+}
@@ -0,0 +1,275 @@
+// Package codegen generates typed Go packages from the protobuf descriptors
+// of a chain, such as the ones saved from a codec.Registry, so that the API
+// of a chain can be pinned at compile time instead of using dynamicpb.
+//
+// Message types are generated by running the protoc-gen-go plugin, which
+// must be installed, while gRPC clients are generated by this package.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// Options configures code generation.
+type Options struct {
+	// ImportPrefix is the Go import path under which packages are generated,
+	// each proto package is generated in ImportPrefix/<proto/package/path>.
+	ImportPrefix string
+	// ImportPaths maps proto file paths to existing Go import paths, in the
+	// form "import/path" or "import/path;packagename". Mapped files are not
+	// generated. Well known types are always mapped to their canonical packages.
+	ImportPaths map[string]string
+	// Filter, if set, selects which of the files which are not mapped
+	// through ImportPaths are generated. Files which are imported
+	// by generated files must be either generated or mapped.
+	Filter func(protoFilePath string) bool
+	// ProtocGenGo is the path of the protoc-gen-go plugin generating the
+	// message types, it defaults to protoc-gen-go looked up in PATH.
+	ProtocGenGo string
+}
+
+// File is a generated Go file.
+type File struct {
+	// Name is the path of the file, relative to Options.ImportPrefix.
+	Name    string
+	Content []byte
+}
+
+// wellKnownImportPaths maps protobuf files to packages which already
+// provide their generated code.
+var wellKnownImportPaths = map[string]string{
+	"google/protobuf/any.proto":             "google.golang.org/protobuf/types/known/anypb",
+	"google/protobuf/api.proto":             "google.golang.org/protobuf/types/known/apipb",
+	"google/protobuf/duration.proto":        "google.golang.org/protobuf/types/known/durationpb",
+	"google/protobuf/empty.proto":           "google.golang.org/protobuf/types/known/emptypb",
+	"google/protobuf/field_mask.proto":      "google.golang.org/protobuf/types/known/fieldmaskpb",
+	"google/protobuf/source_context.proto":  "google.golang.org/protobuf/types/known/sourcecontextpb",
+	"google/protobuf/struct.proto":          "google.golang.org/protobuf/types/known/structpb",
+	"google/protobuf/timestamp.proto":       "google.golang.org/protobuf/types/known/timestamppb",
+	"google/protobuf/type.proto":            "google.golang.org/protobuf/types/known/typepb",
+	"google/protobuf/wrappers.proto":        "google.golang.org/protobuf/types/known/wrapperspb",
+	"google/protobuf/descriptor.proto":      "google.golang.org/protobuf/types/descriptorpb",
+	"google/protobuf/compiler/plugin.proto": "google.golang.org/protobuf/types/pluginpb",
+	"google/api/annotations.proto":          "google.golang.org/genproto/googleapis/api/annotations",
+	"google/api/http.proto":                 "google.golang.org/genproto/googleapis/api/annotations",
+	"google/api/httpbody.proto":             "google.golang.org/genproto/googleapis/api/httpbody",
+}
+
+// Generate generates the Go message types and gRPC clients
+// for the files contained in the FileDescriptorSet.
+func Generate(set *descriptorpb.FileDescriptorSet, opts Options) ([]*File, error) {
+	if opts.ImportPrefix == "" {
+		return nil, fmt.Errorf("no import prefix set")
+	}
+
+	files, err := sortFiles(set.File)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &pluginpb.CodeGeneratorRequest{ProtoFile: files}
+	params := []string{"module=" + opts.ImportPrefix}
+	for _, fd := range files {
+		importPath, mapped := opts.ImportPaths[fd.GetName()]
+		if !mapped {
+			importPath, mapped = wellKnownImportPaths[fd.GetName()]
+		}
+		if !mapped {
+			importPath = packageImportPath(opts.ImportPrefix, fd)
+			if opts.Filter == nil || opts.Filter(fd.GetName()) {
+				req.FileToGenerate = append(req.FileToGenerate, fd.GetName())
+			}
+		}
+		if strings.ContainsAny(fd.GetName(), ",=") {
+			return nil, fmt.Errorf("unsupported file name: %s", fd.GetName())
+		}
+		params = append(params, fmt.Sprintf("M%s=%s", fd.GetName(), importPath))
+	}
+	req.Parameter = proto.String(strings.Join(params, ","))
+
+	messages, err := runPlugin(opts.ProtocGenGo, req)
+	if err != nil {
+		return nil, err
+	}
+
+	plugin, err := protogen.Options{}.New(req)
+	if err != nil {
+		return nil, err
+	}
+	plugin.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+
+	for _, f := range plugin.Files {
+		if f.Generate {
+			generateGRPC(plugin, f)
+		}
+	}
+
+	clients := plugin.Response()
+	if clients.Error != nil {
+		return nil, fmt.Errorf("unable to generate code: %s", clients.GetError())
+	}
+
+	respFiles := append(messages.File, clients.File...)
+	generated := make([]*File, 0, len(respFiles))
+	names := make(map[string]struct{}, len(respFiles))
+	for _, f := range respFiles {
+		if _, exists := names[f.GetName()]; exists {
+			return nil, fmt.Errorf("multiple files generated with the same name: %s", f.GetName())
+		}
+		names[f.GetName()] = struct{}{}
+		generated = append(generated, &File{Name: f.GetName(), Content: []byte(f.GetContent())})
+	}
+
+	return generated, nil
+}
+
+// runPlugin runs the protoc-gen-go plugin found at path, or in PATH if empty.
+func runPlugin(path string, req *pluginpb.CodeGeneratorRequest) (*pluginpb.CodeGeneratorResponse, error) {
+	if path == "" {
+		path = "protoc-gen-go"
+	}
+	in, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(in), &stdout, &stderr
+	err = cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("unable to run %s: %w: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	resp := new(pluginpb.CodeGeneratorResponse)
+	err = proto.Unmarshal(stdout.Bytes(), resp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s response: %w", path, err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("unable to generate code: %s", resp.GetError())
+	}
+	return resp, nil
+}
+
+// WriteDir writes the generated files into dir, which is expected
+// to be the directory corresponding to Options.ImportPrefix.
+func WriteDir(dir string, files []*File) error {
+	for _, f := range files {
+		name := filepath.Clean(filepath.FromSlash(f.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid file path: %s", f.Name)
+		}
+		name = filepath.Join(dir, name)
+
+		err := os.MkdirAll(filepath.Dir(name), 0o755)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(name, f.Content, 0o644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// packageImportPath returns the import path and package name of the Go
+// package generated for the file. Files are grouped by protobuf package,
+// files without one are grouped by directory.
+func packageImportPath(prefix string, fd *descriptorpb.FileDescriptorProto) string {
+	var dir string
+	if fd.GetPackage() != "" {
+		dir = strings.ReplaceAll(fd.GetPackage(), ".", "/")
+	} else {
+		dir = path.Dir(fd.GetName())
+		if dir == "." {
+			dir = strings.TrimSuffix(path.Base(fd.GetName()), ".proto")
+		}
+	}
+
+	return prefix + "/" + dir + ";" + packageName(dir)
+}
+
+// packageName derives the Go package name from the package directory, in the
+// same fashion as cosmos-sdk/api: cosmos/bank/v1beta1 becomes bankv1beta1.
+func packageName(dir string) string {
+	parts := strings.Split(dir, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && isVersion(name) {
+		name = parts[len(parts)-2] + name
+	}
+
+	b := new(strings.Builder)
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9' && b.Len() != 0:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "pb"
+	}
+	return b.String()
+}
+
+func isVersion(s string) bool {
+	return len(s) > 1 && s[0] == 'v' && s[1] >= '0' && s[1] <= '9'
+}
+
+// sortFiles sorts files so that each file comes after its dependencies.
+func sortFiles(files []*descriptorpb.FileDescriptorProto) ([]*descriptorpb.FileDescriptorProto, error) {
+	byName := make(map[string]*descriptorpb.FileDescriptorProto, len(files))
+	names := make([]string, 0, len(files))
+	for _, fd := range files {
+		byName[fd.GetName()] = fd
+		names = append(names, fd.GetName())
+	}
+	sort.Strings(names)
+
+	sorted := make([]*descriptorpb.FileDescriptorProto, 0, len(files))
+	state := make(map[string]int, len(files)) // 1: visiting, 2: done
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("import cycle detected at %s", name)
+		case 2:
+			return nil
+		}
+		fd, exists := byName[name]
+		if !exists {
+			return fmt.Errorf("missing dependency: %s", name)
+		}
+		state[name] = 1
+		for _, dep := range fd.Dependency {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		sorted = append(sorted, fd)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
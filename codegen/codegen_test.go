@@ -0,0 +1,97 @@
+package codegen
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/cosmos/cosmos-proto"
+	"github.com/stretchr/testify/require"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func findFile(files []*File, name string) *File {
+	for _, f := range files {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// protocGenGo builds protoc-gen-go at the version of google.golang.org/protobuf required by the module.
+func protocGenGo(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "protoc-gen-go")
+	out, err := exec.Command("go", "build", "-o", path, "google.golang.org/protobuf/cmd/protoc-gen-go").CombinedOutput()
+	if err != nil {
+		t.Skipf("unable to build protoc-gen-go: %s: %s", err, out)
+	}
+	return path
+}
+
+func TestGenerate(t *testing.T) {
+	b, err := os.ReadFile("../data/osmosis.proto.json")
+	require.NoError(t, err)
+	set := new(descriptorpb.FileDescriptorSet)
+	require.NoError(t, protojson.Unmarshal(b, set))
+
+	files, err := Generate(set, Options{
+		ImportPrefix: "example.com/chain",
+		ProtocGenGo:  protocGenGo(t),
+		Filter: func(path string) bool {
+			return !strings.HasPrefix(path, "osmosis/")
+		},
+	})
+	require.NoError(t, err)
+
+	for _, f := range files {
+		require.False(t, strings.HasPrefix(f.Name, "osmosis/"), f.Name)
+		require.False(t, strings.HasPrefix(f.Name, "google/"), f.Name)
+	}
+
+	query := findFile(files, "cosmos/bank/v1beta1/query.pb.go")
+	require.NotNil(t, query)
+	require.Contains(t, string(query.Content), "package bankv1beta1\n")
+	require.Contains(t, string(query.Content), "type QueryBalanceRequest struct {")
+	require.Contains(t, string(query.Content), `"example.com/chain/cosmos/base/v1beta1"`)
+
+	client := findFile(files, "cosmos/bank/v1beta1/query_grpc.pb.go")
+	require.NotNil(t, client)
+	require.Contains(t, string(client.Content), "func NewQueryClient(cc grpc.ClientConnInterface) QueryClient {")
+	require.Contains(t, string(client.Content), `c.cc.Invoke(ctx, "/cosmos.bank.v1beta1.Query/Balance", in, out, opts...)`)
+}
+
+func TestGenerate_Streaming(t *testing.T) {
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("stream/v1/stream.proto"),
+		Package: proto.String("stream.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Request")},
+			{Name: proto.String("Response")},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Stream"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("Server"), InputType: proto.String(".stream.v1.Request"), OutputType: proto.String(".stream.v1.Response"), ServerStreaming: proto.Bool(true)},
+				{Name: proto.String("Client"), InputType: proto.String(".stream.v1.Request"), OutputType: proto.String(".stream.v1.Response"), ClientStreaming: proto.Bool(true)},
+			},
+		}},
+	}}}
+
+	files, err := Generate(set, Options{ImportPrefix: "example.com/chain", ProtocGenGo: protocGenGo(t)})
+	require.NoError(t, err)
+
+	client := findFile(files, "stream/v1/stream_grpc.pb.go")
+	require.NotNil(t, client)
+	require.Contains(t, string(client.Content), "package streamv1\n")
+	require.Contains(t, string(client.Content), "Server(ctx context.Context, in *Request, opts ...grpc.CallOption) (Stream_ServerClient, error)")
+	require.Contains(t, string(client.Content), "Recv() (*Response, error)")
+	require.Contains(t, string(client.Content), "Client(ctx context.Context, opts ...grpc.CallOption) (Stream_ClientClient, error)")
+	require.Contains(t, string(client.Content), "CloseAndRecv() (*Response, error)")
+}
@@ -0,0 +1,165 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	contextPackage = protogen.GoImportPath("context")
+	grpcPackage    = protogen.GoImportPath("google.golang.org/grpc")
+)
+
+// generateGRPC generates the gRPC clients of the services defined in file.
+// The clients only rely on grpc.ClientConnInterface, hence they can be used
+// with a connection using the codec.Codec gRPC codec.
+func generateGRPC(gen *protogen.Plugin, file *protogen.File) {
+	if len(file.Services) == 0 {
+		return
+	}
+
+	g := gen.NewGeneratedFile(file.GeneratedFilenamePrefix+"_grpc.pb.go", file.GoImportPath)
+	g.P("// Code generated by dynamic-cosmos codegen. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+
+	for _, svc := range file.Services {
+		generateClient(g, svc)
+	}
+}
+
+func generateClient(g *protogen.GeneratedFile, svc *protogen.Service) {
+	clientName := svc.GoName + "Client"
+	implName := unexport(clientName)
+	connInterface := g.QualifiedGoIdent(grpcPackage.Ident("ClientConnInterface"))
+
+	g.P("// ", clientName, " is the client API for the ", svc.Desc.FullName(), " service.")
+	g.P("type ", clientName, " interface {")
+	for _, m := range svc.Methods {
+		g.P(m.Comments.Leading, methodSignature(g, m))
+	}
+	g.P("}")
+	g.P()
+
+	g.P("type ", implName, " struct {")
+	g.P("cc ", connInterface)
+	g.P("}")
+	g.P()
+
+	g.P("// New", clientName, " returns a ", clientName, " using the provided connection.")
+	g.P("func New", clientName, "(cc ", connInterface, ") ", clientName, " {")
+	g.P("return &", implName, "{cc}")
+	g.P("}")
+	g.P()
+
+	for i, m := range svc.Methods {
+		route := fmt.Sprintf("/%s/%s", svc.Desc.FullName(), m.Desc.Name())
+		streamType := unexport(svc.GoName) + m.GoName + "Client"
+		streamInterface := svc.GoName + "_" + m.GoName + "Client"
+
+		if !m.Desc.IsStreamingClient() && !m.Desc.IsStreamingServer() {
+			g.P("func (c *", implName, ") ", methodSignature(g, m), " {")
+			g.P("out := new(", g.QualifiedGoIdent(m.Output.GoIdent), ")")
+			g.P("err := c.cc.Invoke(ctx, ", fmt.Sprintf("%q", route), ", in, out, opts...)")
+			g.P("if err != nil {")
+			g.P("return nil, err")
+			g.P("}")
+			g.P("return out, nil")
+			g.P("}")
+			g.P()
+			continue
+		}
+
+		streamDesc := g.QualifiedGoIdent(grpcPackage.Ident("StreamDesc"))
+		g.P("func (c *", implName, ") ", methodSignature(g, m), " {")
+		g.P("stream, err := c.cc.NewStream(ctx, &", streamDesc, "{StreamName: ", fmt.Sprintf("%q", m.Desc.Name()),
+			", ServerStreams: ", m.Desc.IsStreamingServer(), ", ClientStreams: ", m.Desc.IsStreamingClient(), "}, ",
+			fmt.Sprintf("%q", route), ", opts...)")
+		g.P("if err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		g.P("x := &", streamType, "{stream}")
+		if !m.Desc.IsStreamingClient() {
+			g.P("if err := x.ClientStream.SendMsg(in); err != nil {")
+			g.P("return nil, err")
+			g.P("}")
+			g.P("if err := x.ClientStream.CloseSend(); err != nil {")
+			g.P("return nil, err")
+			g.P("}")
+		}
+		g.P("return x, nil")
+		g.P("}")
+		g.P()
+
+		clientStream := g.QualifiedGoIdent(grpcPackage.Ident("ClientStream"))
+		input, output := g.QualifiedGoIdent(m.Input.GoIdent), g.QualifiedGoIdent(m.Output.GoIdent)
+
+		g.P("// ", streamInterface, " is the stream returned by ", clientName, ".", m.GoName, ".")
+		g.P("type ", streamInterface, " interface {")
+		if m.Desc.IsStreamingClient() {
+			g.P("Send(*", input, ") error")
+		}
+		if m.Desc.IsStreamingServer() {
+			g.P("Recv() (*", output, ", error)")
+		} else {
+			g.P("CloseAndRecv() (*", output, ", error)")
+		}
+		g.P(clientStream)
+		g.P("}")
+		g.P()
+
+		g.P("type ", streamType, " struct {")
+		g.P(clientStream)
+		g.P("}")
+		g.P()
+		if m.Desc.IsStreamingClient() {
+			g.P("func (x *", streamType, ") Send(m *", input, ") error {")
+			g.P("return x.ClientStream.SendMsg(m)")
+			g.P("}")
+			g.P()
+		}
+		if m.Desc.IsStreamingServer() {
+			g.P("func (x *", streamType, ") Recv() (*", output, ", error) {")
+		} else {
+			g.P("func (x *", streamType, ") CloseAndRecv() (*", output, ", error) {")
+			g.P("if err := x.ClientStream.CloseSend(); err != nil {")
+			g.P("return nil, err")
+			g.P("}")
+		}
+		g.P("m := new(", output, ")")
+		g.P("if err := x.ClientStream.RecvMsg(m); err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		g.P("return m, nil")
+		g.P("}")
+		if i != len(svc.Methods)-1 {
+			g.P()
+		}
+	}
+}
+
+func methodSignature(g *protogen.GeneratedFile, m *protogen.Method) string {
+	ctx := g.QualifiedGoIdent(contextPackage.Ident("Context"))
+	callOption := g.QualifiedGoIdent(grpcPackage.Ident("CallOption"))
+
+	s := m.GoName + "(ctx " + ctx
+	if !m.Desc.IsStreamingClient() {
+		s += ", in *" + g.QualifiedGoIdent(m.Input.GoIdent)
+	}
+	s += ", opts ..." + callOption + ") "
+	if m.Desc.IsStreamingClient() || m.Desc.IsStreamingServer() {
+		return s + "(" + m.Parent.GoName + "_" + m.GoName + "Client, error)"
+	}
+	return s + "(*" + g.QualifiedGoIdent(m.Output.GoIdent) + ", error)"
+}
+
+func unexport(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,54 @@
+package dynamic
+
+import (
+	"errors"
+	"strings"
+)
+
+// MultiError reports multiple failures, for example
+// the ones occurred while closing a Client.
+type MultiError struct {
+	Errors []error
+}
+
+func (e *MultiError) Error() string {
+	reasons := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		reasons[i] = err.Error()
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// Is reports if any of the errors matches target.
+func (e *MultiError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error matching target.
+func (e *MultiError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// joinErrors returns a *MultiError containing the non nil errors, or nil if there are none.
+func joinErrors(errs ...error) error {
+	var nonNil []error
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+	if len(nonNil) == 0 {
+		return nil
+	}
+	return &MultiError{Errors: nonNil}
+}
@@ -0,0 +1,22 @@
+package dynamic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinErrors(t *testing.T) {
+	require.NoError(t, joinErrors(nil, nil))
+
+	err := joinErrors(nil, fmt.Errorf("watcher: %w", context.Canceled), errors.New("conn"))
+	require.ErrorIs(t, err, context.Canceled)
+	require.EqualError(t, err, "watcher: context canceled; conn")
+
+	var multi *MultiError
+	require.ErrorAs(t, err, &multi)
+	require.Len(t, multi.Errors, 2)
+}
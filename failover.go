@@ -0,0 +1,376 @@
+package dynamic
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	tendermintv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+	"github.com/fdymylja/dynamic-cosmos/tx"
+	"github.com/tendermint/tendermint/rpc/client/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultHealthCheckInterval = 10 * time.Second
+	defaultMaxBlockLag         = 10
+)
+
+// WithHealthCheckInterval sets how often nodes are health checked
+// when the Client is dialed with multiple endpoints, it must be positive.
+func WithHealthCheckInterval(interval time.Duration) DialOption {
+	return func(options *options) {
+		options.healthInterval = interval
+	}
+}
+
+// WithMaxBlockLag sets how many blocks a node can lag behind the most
+// up-to-date node before being avoided.
+func WithMaxBlockLag(blocks int64) DialOption {
+	return func(options *options) {
+		options.maxBlockLag = blocks
+	}
+}
+
+// nodeStatus is the outcome of a node health check.
+type nodeStatus struct {
+	height     int64
+	catchingUp bool
+	err        error
+}
+
+// nodeScores scores the nodes statuses, zero means healthy: the node is reachable,
+// not catching up and not lagging behind the highest node by more than maxLag blocks.
+func nodeScores(statuses []nodeStatus, maxLag int64) []int {
+	var maxHeight int64
+	for _, s := range statuses {
+		if s.err == nil && s.height > maxHeight {
+			maxHeight = s.height
+		}
+	}
+
+	scores := make([]int, len(statuses))
+	for i, s := range statuses {
+		switch {
+		case s.err != nil:
+			scores[i] = 3
+		case s.catchingUp:
+			scores[i] = 2
+		case maxHeight-s.height > maxLag:
+			scores[i] = 1
+		}
+	}
+	return scores
+}
+
+// rankNodes returns the indexes of the nodes sorted by preference: healthy nodes
+// first in the order they were provided, then the others from the highest.
+func rankNodes(statuses []nodeStatus, maxLag int64) []int {
+	scores := nodeScores(statuses, maxLag)
+
+	ranked := make([]int, len(statuses))
+	for i := range ranked {
+		ranked[i] = i
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if scores[a] != scores[b] {
+			return scores[a] < scores[b]
+		}
+		if scores[a] == 0 {
+			return false
+		}
+		return statuses[a].height > statuses[b].height
+	})
+	return ranked
+}
+
+var _ grpc.ClientConnInterface = (*balancedConn)(nil)
+
+// balancedConn routes calls to the healthiest of multiple gRPC
+// connections, failing over to the others when a node is unavailable.
+type balancedConn struct {
+	endpoints []string
+	conns     []*grpc.ClientConn
+	maxLag    int64
+
+	mu       sync.RWMutex
+	statuses []nodeStatus
+}
+
+func dialBalancedConn(ctx context.Context, endpoints []string, maxLag int64, opts ...grpc.DialOption) (*balancedConn, error) {
+	b := &balancedConn{
+		endpoints: endpoints,
+		conns:     make([]*grpc.ClientConn, len(endpoints)),
+		maxLag:    maxLag,
+		statuses:  make([]nodeStatus, len(endpoints)),
+	}
+	for i, endpoint := range endpoints {
+		conn, err := grpc.DialContext(ctx, endpoint, opts...)
+		if err != nil {
+			_ = b.Close()
+			return nil, fmt.Errorf("unable to dial %s: %w", endpoint, err)
+		}
+		b.conns[i] = conn
+	}
+
+	b.check(ctx)
+	return b, nil
+}
+
+func (b *balancedConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	var err error
+	for _, i := range b.ranked() {
+		err = b.conns[i].Invoke(ctx, method, args, reply, opts...)
+		if !isUnavailable(err) {
+			return err
+		}
+		b.markDown(i, err)
+	}
+	return err
+}
+
+func (b *balancedConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	var err error
+	for _, i := range b.ranked() {
+		var stream grpc.ClientStream
+		stream, err = b.conns[i].NewStream(ctx, desc, method, opts...)
+		if !isUnavailable(err) {
+			return stream, err
+		}
+		b.markDown(i, err)
+	}
+	return nil, err
+}
+
+func (b *balancedConn) ranked() []int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return rankNodes(b.statuses, b.maxLag)
+}
+
+// markDown deprioritizes the node until the next health check.
+func (b *balancedConn) markDown(i int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.statuses[i].err = err
+}
+
+// check health checks all nodes concurrently.
+func (b *balancedConn) check(ctx context.Context) {
+	statuses := make([]nodeStatus, len(b.conns))
+	wg := new(sync.WaitGroup)
+	for i, conn := range b.conns {
+		wg.Add(1)
+		go func(i int, conn *grpc.ClientConn) {
+			defer wg.Done()
+			statuses[i] = grpcNodeStatus(ctx, conn)
+		}(i, conn)
+	}
+	wg.Wait()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.statuses = statuses
+}
+
+func (b *balancedConn) Close() error {
+	errs := make([]error, 0, len(b.conns))
+	for i, conn := range b.conns {
+		if conn == nil {
+			continue
+		}
+		err := conn.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.endpoints[i], err))
+		}
+	}
+	return joinErrors(errs...)
+}
+
+func grpcNodeStatus(ctx context.Context, conn grpc.ClientConnInterface) nodeStatus {
+	svc := tendermintv1beta1.NewServiceClient(conn)
+	syncing, err := svc.GetSyncing(ctx, &tendermintv1beta1.GetSyncingRequest{})
+	if err != nil {
+		return nodeStatus{err: err}
+	}
+	block, err := svc.GetLatestBlock(ctx, &tendermintv1beta1.GetLatestBlockRequest{})
+	if err != nil {
+		return nodeStatus{err: err}
+	}
+	return nodeStatus{
+		height:     block.GetBlock().GetHeader().GetHeight(),
+		catchingUp: syncing.Syncing,
+	}
+}
+
+func isUnavailable(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+// tmPool tracks multiple tendermint endpoints, only the current one is started
+// and used by the watcher, the others are only used to check their status.
+type tmPool struct {
+	endpoints []string
+	clients   []*http.HTTP
+	maxLag    int64
+
+	mu      sync.RWMutex
+	current int
+}
+
+func newTMPool(endpoints []string, maxLag int64) (*tmPool, error) {
+	p := &tmPool{
+		endpoints: endpoints,
+		clients:   make([]*http.HTTP, len(endpoints)),
+		maxLag:    maxLag,
+	}
+	for i, endpoint := range endpoints {
+		tm, err := http.New(endpoint, "/websocket")
+		if err != nil {
+			return nil, fmt.Errorf("unable to create tendermint client for %s: %w", endpoint, err)
+		}
+		p.clients[i] = tm
+	}
+	return p, nil
+}
+
+// start starts the healthiest client.
+func (p *tmPool) start(ctx context.Context) (*http.HTTP, error) {
+	var err error
+	for _, i := range rankNodes(p.statuses(ctx), p.maxLag) {
+		err = p.clients[i].Start()
+		if err == nil {
+			p.current = i
+			return p.clients[i], nil
+		}
+		err = p.replace(i)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+func (p *tmPool) statuses(ctx context.Context) []nodeStatus {
+	statuses := make([]nodeStatus, len(p.clients))
+	if len(p.clients) == 1 {
+		return statuses
+	}
+
+	wg := new(sync.WaitGroup)
+	for i, tm := range p.clients {
+		wg.Add(1)
+		go func(i int, tm *http.HTTP) {
+			defer wg.Done()
+			res, err := tm.Status(ctx)
+			if err != nil {
+				statuses[i] = nodeStatus{err: err}
+				return
+			}
+			statuses[i] = nodeStatus{height: res.SyncInfo.LatestBlockHeight, catchingUp: res.SyncInfo.CatchingUp}
+		}(i, tm)
+	}
+	wg.Wait()
+	return statuses
+}
+
+// failover moves the watcher to the healthiest client if the current one is not healthy.
+func (p *tmPool) failover(ctx context.Context, watcher *tx.Watcher) error {
+	statuses := p.statuses(ctx)
+	p.mu.RLock()
+	current := p.current
+	p.mu.RUnlock()
+
+	best := rankNodes(statuses, p.maxLag)[0]
+	scores := nodeScores(statuses, p.maxLag)
+	if scores[current] == 0 || scores[best] >= scores[current] {
+		return nil
+	}
+
+	next := p.clients[best]
+	err := next.Start()
+	if err != nil {
+		_ = p.replace(best)
+		return err
+	}
+	err = watcher.Switch(ctx, next)
+	if err != nil {
+		_ = next.Stop()
+		_ = p.replace(best)
+		return err
+	}
+
+	p.mu.Lock()
+	p.current = best
+	p.mu.Unlock()
+
+	_ = p.clients[current].Stop()
+	return p.replace(current)
+}
+
+// replace replaces a stopped client, as they can't be restarted.
+func (p *tmPool) replace(i int) error {
+	tm, err := http.New(p.endpoints[i], "/websocket")
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clients[i] = tm
+	return nil
+}
+
+func (p *tmPool) get() *http.HTTP {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.clients[p.current]
+}
+
+func (p *tmPool) stop() error {
+	return p.get().Stop()
+}
+
+// healthMonitor periodically health checks the endpoints and fails over.
+type healthMonitor struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func startHealthMonitor(interval time.Duration, conn *balancedConn, tm *tmPool, watcher *tx.Watcher) *healthMonitor {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &healthMonitor{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			checkCtx, cancelCheck := context.WithTimeout(ctx, interval)
+			if conn != nil {
+				conn.check(checkCtx)
+			}
+			if tm != nil && watcher != nil && len(tm.clients) > 1 {
+				// on failure the current node is kept until the next check
+				_ = tm.failover(checkCtx, watcher)
+			}
+			cancelCheck()
+		}
+	}()
+	return m
+}
+
+func (m *healthMonitor) stop() {
+	m.cancel()
+	<-m.done
+}
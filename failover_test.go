@@ -0,0 +1,85 @@
+package dynamic
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	tendermintv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+	tmtypes "github.com/cosmos/cosmos-sdk/api/tendermint/types"
+	"github.com/fdymylja/dynamic-cosmos/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestRankNodes(t *testing.T) {
+	statuses := []nodeStatus{
+		{err: fmt.Errorf("unreachable")},
+		{height: 200, catchingUp: true},
+		{height: 100},
+		{height: 195},
+		{height: 200},
+	}
+	require.Equal(t, []int{3, 4, 2, 1, 0}, rankNodes(statuses, 10))
+}
+
+// nodeService reports a fixed node status.
+type nodeService struct {
+	tendermintv1beta1.UnimplementedServiceServer
+	height  int64
+	syncing bool
+}
+
+func (n nodeService) GetSyncing(context.Context, *tendermintv1beta1.GetSyncingRequest) (*tendermintv1beta1.GetSyncingResponse, error) {
+	return &tendermintv1beta1.GetSyncingResponse{Syncing: n.syncing}, nil
+}
+
+func (n nodeService) GetLatestBlock(context.Context, *tendermintv1beta1.GetLatestBlockRequest) (*tendermintv1beta1.GetLatestBlockResponse, error) {
+	return &tendermintv1beta1.GetLatestBlockResponse{Block: &tmtypes.Block{Header: &tmtypes.Header{Height: n.height}}}, nil
+}
+
+func TestBalancedConn(t *testing.T) {
+	newNode := func(svc nodeService) (*grpc.ClientConn, *grpc.Server) {
+		var srv *grpc.Server
+		conn := testutil.NewConn(t, func(s *grpc.Server) {
+			srv = s
+			tendermintv1beta1.RegisterServiceServer(s, &svc)
+		})
+		return conn, srv
+	}
+
+	syncingConn, _ := newNode(nodeService{height: 100, syncing: true})
+	firstConn, firstSrv := newNode(nodeService{height: 100})
+	secondConn, _ := newNode(nodeService{height: 99})
+
+	conn := &balancedConn{
+		conns:    []*grpc.ClientConn{syncingConn, firstConn, secondConn},
+		maxLag:   10,
+		statuses: make([]nodeStatus, 3),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn.check(ctx)
+	require.Equal(t, []int{1, 2, 0}, conn.ranked())
+
+	health := grpc_health_v1.NewHealthClient(conn)
+	_, err := health.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	// the first healthy node goes down, calls fail over to the second
+	firstSrv.Stop()
+	_, err = health.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 0, 1}, conn.ranked())
+}
+
+func TestDialEndpoints_InvalidHealthOptions(t *testing.T) {
+	endpoints := []string{"localhost:9090", "localhost:9091"}
+	_, err := DialEndpoints(context.Background(), endpoints, nil, WithHealthCheckInterval(0))
+	require.ErrorContains(t, err, "invalid health check interval")
+	_, err = DialEndpoints(context.Background(), endpoints, nil, WithMaxBlockLag(-1))
+	require.ErrorContains(t, err, "invalid max block lag")
+}
@@ -0,0 +1,308 @@
+// Package gateway exposes the query services discovered by a dynamic.Client as HTTP JSON endpoints.
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	dynamic "github.com/fdymylja/dynamic-cosmos"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	// heightHeader is the header selecting the queried height, and reporting the served one.
+	heightHeader = "X-Cosmos-Block-Height"
+	// maxBodySize is the maximum size of request bodies.
+	maxBodySize = 1 << 20
+)
+
+// Route is an HTTP route of a query method.
+type Route struct {
+	// HTTPMethod is the HTTP method, ex: GET.
+	HTTPMethod string
+	// Pattern is the path template, ex: /cosmos/bank/v1beta1/balances/{address}.
+	Pattern string
+	// Body is the request field set from the body, * for the whole request, or empty.
+	Body   string
+	Method *dynamic.QueryMethod
+
+	pattern *pattern
+}
+
+// Gateway is an http.Handler serving the query methods of the Client. Methods annotated with
+// google.api.http are served on their annotated routes, every method is also served on the
+// generic /<service>/<method> route, ex: /cosmos.bank.v1beta1.Query/AllBalances, using GET
+// with the request fields set from the query parameters or POST with the JSON request as body.
+//
+// Request fields are set from the path variables and the query parameters, nested fields are
+// referenced using dotted names, so that paginated queries accept pagination.key, pagination.limit,
+// etc. As in the grpc-gateway, the X-Cosmos-Block-Height header selects the queried height, and
+// the served height is reported using the X-Cosmos-Block-Height response header.
+type Gateway struct {
+	client  *dynamic.Client
+	routes  []*Route
+	generic map[string]*Route
+}
+
+// New creates a Gateway serving the query methods of the client catalog.
+func New(client *dynamic.Client) (*Gateway, error) {
+	catalog, err := client.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Gateway{client: client, generic: map[string]*Route{}}
+	for _, svc := range catalog.QueryServices() {
+		for _, method := range svc.Methods {
+			if method.Descriptor.IsStreamingClient() || method.Descriptor.IsStreamingServer() {
+				continue
+			}
+			rules, err := httpRules(method)
+			if err != nil {
+				return nil, err
+			}
+			for _, rule := range rules {
+				route, err := newRoute(rule, method)
+				if err != nil {
+					return nil, fmt.Errorf("invalid http rule of %s: %w", method.Descriptor.FullName(), err)
+				}
+				g.routes = append(g.routes, route)
+			}
+			g.generic[method.Path] = &Route{Pattern: method.Path, Method: method}
+		}
+	}
+	return g, nil
+}
+
+// Routes returns the routes built from the google.api.http annotations.
+func (g *Gateway) Routes() []Route {
+	routes := make([]Route, len(g.routes))
+	for i, r := range g.routes {
+		routes[i] = *r
+	}
+	return routes
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, vars := g.match(r)
+	if route == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no route for %s %s", r.Method, r.URL.Path))
+		return
+	}
+
+	req, err := g.request(r, route, vars)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var opts []dynamic.QueryOption
+	if height := r.Header.Get(heightHeader); height != "" {
+		h, err := strconv.ParseInt(height, 10, 64)
+		if err != nil || h < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid height %q", height))
+			return
+		}
+		opts = append(opts, dynamic.AtHeight(h))
+	}
+	var served int64
+	opts = append(opts, dynamic.ServedHeight(&served))
+
+	resp := dynamicpb.NewMessage(route.Method.Response)
+	err = g.client.DynamicQuery(r.Context(), route.Method.Path, req, resp, opts...)
+	if err != nil {
+		code := httpStatus(status.Code(err))
+		if errors.Is(err, dynamic.ErrHeightUnavailable) {
+			code = http.StatusBadRequest
+		}
+		writeError(w, code, err)
+		return
+	}
+
+	b, err := g.client.Codec.MarshalProtoJSON(resp)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if served != 0 {
+		w.Header().Set(heightHeader, strconv.FormatInt(served, 10))
+	}
+	_, _ = w.Write(b)
+}
+
+// match returns the route matching the request, and its path variables.
+func (g *Gateway) match(r *http.Request) (*Route, map[string]string) {
+	for _, route := range g.routes {
+		if route.HTTPMethod != r.Method {
+			continue
+		}
+		if vars, ok := route.pattern.match(r.URL.Path); ok {
+			return route, vars
+		}
+	}
+	if route, ok := g.generic[r.URL.Path]; ok && (r.Method == http.MethodGet || r.Method == http.MethodPost) {
+		generic := *route
+		if r.Method == http.MethodPost {
+			generic.Body = "*"
+		}
+		return &generic, nil
+	}
+	return nil, nil
+}
+
+// request builds the query request from the body, the path variables and the query parameters.
+func (g *Gateway) request(r *http.Request, route *Route, vars map[string]string) (*dynamicpb.Message, error) {
+	req := dynamicpb.NewMessage(route.Method.Request)
+
+	if route.Body != "" {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+		if err != nil {
+			return nil, err
+		}
+		if len(body) != 0 {
+			target := proto.Message(req)
+			if route.Body != "*" {
+				fd := req.Descriptor().Fields().ByName(protoreflect.Name(route.Body))
+				if fd == nil || fd.Message() == nil {
+					return nil, fmt.Errorf("invalid body field %s", route.Body)
+				}
+				target = req.Mutable(fd).Message().Interface()
+			}
+			err = g.client.Codec.UnmarshalProtoJSON(body, target)
+			if err != nil {
+				return nil, fmt.Errorf("invalid body: %w", err)
+			}
+		}
+	}
+
+	for field, value := range vars {
+		err := setField(req, field, []string{value})
+		if err != nil {
+			return nil, err
+		}
+	}
+	// with the whole request as body the query parameters are not bound
+	if route.Body == "*" {
+		return req, nil
+	}
+	for param, values := range r.URL.Query() {
+		if _, isVar := vars[param]; isVar {
+			continue
+		}
+		err := setField(req, param, values)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// httpRules returns the google.api.http rules of the method, including the additional bindings.
+func httpRules(method *dynamic.QueryMethod) ([]*annotations.HttpRule, error) {
+	opts, ok := method.Descriptor.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil {
+		return nil, nil
+	}
+	// the extension is unknown if the options were decoded before it was registered
+	if !proto.HasExtension(opts, annotations.E_Http) && len(opts.ProtoReflect().GetUnknown()) != 0 {
+		b, err := proto.Marshal(opts)
+		if err != nil {
+			return nil, err
+		}
+		opts = new(descriptorpb.MethodOptions)
+		err = proto.UnmarshalOptions{Resolver: protoregistry.GlobalTypes}.Unmarshal(b, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !proto.HasExtension(opts, annotations.E_Http) {
+		return nil, nil
+	}
+
+	rule := proto.GetExtension(opts, annotations.E_Http).(*annotations.HttpRule)
+	return append([]*annotations.HttpRule{rule}, rule.AdditionalBindings...), nil
+}
+
+func newRoute(rule *annotations.HttpRule, method *dynamic.QueryMethod) (*Route, error) {
+	var httpMethod, template string
+	switch p := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		httpMethod, template = http.MethodGet, p.Get
+	case *annotations.HttpRule_Post:
+		httpMethod, template = http.MethodPost, p.Post
+	case *annotations.HttpRule_Put:
+		httpMethod, template = http.MethodPut, p.Put
+	case *annotations.HttpRule_Delete:
+		httpMethod, template = http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		httpMethod, template = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		httpMethod, template = p.Custom.Kind, p.Custom.Path
+	default:
+		return nil, fmt.Errorf("missing pattern")
+	}
+
+	pattern, err := parsePattern(template)
+	if err != nil {
+		return nil, err
+	}
+	return &Route{
+		HTTPMethod: httpMethod,
+		Pattern:    template,
+		Body:       rule.Body,
+		Method:     method,
+		pattern:    pattern,
+	}, nil
+}
+
+// writeError writes the error as JSON, in the same format as the grpc-gateway.
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}{Code: int(status.Code(err)), Message: err.Error()})
+}
+
+// httpStatus maps gRPC status codes to HTTP status codes.
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,179 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	bankv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/bank/v1beta1"
+	queryv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/query/v1beta1"
+	reflectionv2alpha1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/reflection/v2alpha1"
+	basev1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/v1beta1"
+	govv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/gov/v1beta1"
+	stakingv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/staking/v1beta1"
+	"github.com/fdymylja/dynamic-cosmos/internal/testutil/testclient"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// bank serves a balance per denom, reporting height 10.
+type bank struct {
+	bankv1beta1.UnimplementedQueryServer
+}
+
+func (bank) Balance(ctx context.Context, req *bankv1beta1.QueryBalanceRequest) (*bankv1beta1.QueryBalanceResponse, error) {
+	if req.Address == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty address")
+	}
+	height := "10"
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("x-cosmos-block-height"); len(values) != 0 {
+		height = values[0]
+	}
+	err := grpc.SetHeader(ctx, metadata.Pairs("x-cosmos-block-height", height))
+	if err != nil {
+		return nil, err
+	}
+	return &bankv1beta1.QueryBalanceResponse{Balance: &basev1beta1.Coin{Denom: req.Denom, Amount: "1"}}, nil
+}
+
+func (bank) AllBalances(_ context.Context, req *bankv1beta1.QueryAllBalancesRequest) (*bankv1beta1.QueryAllBalancesResponse, error) {
+	resp := &bankv1beta1.QueryAllBalancesResponse{Pagination: &queryv1beta1.PageResponse{}}
+	for i := uint64(0); i < req.Pagination.GetLimit(); i++ {
+		resp.Balances = append(resp.Balances, &basev1beta1.Coin{Denom: "denom" + strconv.FormatUint(i, 10), Amount: "1"})
+	}
+	return resp, nil
+}
+
+// staking serves the historical info of the requested height.
+type staking struct {
+	stakingv1beta1.UnimplementedQueryServer
+}
+
+func (staking) HistoricalInfo(_ context.Context, req *stakingv1beta1.QueryHistoricalInfoRequest) (*stakingv1beta1.QueryHistoricalInfoResponse, error) {
+	return &stakingv1beta1.QueryHistoricalInfoResponse{Hist: &stakingv1beta1.HistoricalInfo{
+		Valset: []*stakingv1beta1.Validator{{OperatorAddress: strconv.FormatInt(req.Height, 10)}},
+	}}, nil
+}
+
+func newTestGateway(t *testing.T) *Gateway {
+	c := testclient.Dial(t, &reflectionv2alpha1.AppDescriptor{
+		Chain:         &reflectionv2alpha1.ChainDescriptor{Id: "test-1"},
+		Configuration: &reflectionv2alpha1.ConfigurationDescriptor{Bech32AccountAddressPrefix: "cosmos"},
+		QueryServices: &reflectionv2alpha1.QueryServicesDescriptor{QueryServices: []*reflectionv2alpha1.QueryServiceDescriptor{
+			{Fullname: "cosmos.bank.v1beta1.Query", IsModule: true},
+			{Fullname: "cosmos.staking.v1beta1.Query", IsModule: true},
+		}},
+		Tx: &reflectionv2alpha1.TxDescriptor{},
+	}, func(srv *grpc.Server) {
+		bankv1beta1.RegisterQueryServer(srv, &bank{})
+		stakingv1beta1.RegisterQueryServer(srv, &staking{})
+	})
+
+	g, err := New(c)
+	require.NoError(t, err)
+	return g
+}
+
+func serve(g *Gateway, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	return w
+}
+
+func TestGateway(t *testing.T) {
+	g := newTestGateway(t)
+
+	var patterns []string
+	for _, r := range g.Routes() {
+		patterns = append(patterns, r.HTTPMethod+" "+r.Pattern)
+	}
+	require.Contains(t, patterns, "GET /cosmos/bank/v1beta1/balances/{address}")
+
+	// annotated route, with path variables and query parameters
+	w := serve(g, http.MethodGet, "/cosmos/bank/v1beta1/balances/cosmos1abc/by_denom?denom=stake", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.JSONEq(t, `{"balance":{"denom":"stake","amount":"1"}}`, w.Body.String())
+	require.Equal(t, "10", w.Header().Get(heightHeader))
+
+	// pagination
+	w = serve(g, http.MethodGet, "/cosmos/bank/v1beta1/balances/cosmos1abc?pagination.limit=2", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var all struct {
+		Balances []json.RawMessage `json:"balances"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &all))
+	require.Len(t, all.Balances, 2)
+
+	// generic routes and height
+	w = serve(g, http.MethodGet, "/cosmos.bank.v1beta1.Query/Balance?address=cosmos1abc&denom=stake", "", http.Header{heightHeader: {"5"}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "5", w.Header().Get(heightHeader))
+	w = serve(g, http.MethodPost, "/cosmos.bank.v1beta1.Query/Balance", `{"address":"cosmos1abc","denom":"stake"}`, http.Header{heightHeader: {"7"}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "7", w.Header().Get(heightHeader))
+
+	// the height parameter is bound to the request field
+	w = serve(g, http.MethodGet, "/cosmos.staking.v1beta1.Query/HistoricalInfo?height=5", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Contains(t, w.Body.String(), `"operatorAddress":"5"`)
+	require.Equal(t, "", w.Header().Get(heightHeader))
+
+	// errors
+	w = serve(g, http.MethodGet, "/cosmos.bank.v1beta1.Query/Balance?denom=stake", "", nil)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "empty address")
+	w = serve(g, http.MethodGet, "/cosmos.bank.v1beta1.Query/Balance?unknown=1", "", nil)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(g, http.MethodGet, "/missing", "", nil)
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPattern(t *testing.T) {
+	p, err := parsePattern("/cosmos/tx/v1beta1/txs/{hash}")
+	require.NoError(t, err)
+	vars, ok := p.match("/cosmos/tx/v1beta1/txs/ABCD")
+	require.True(t, ok)
+	require.Equal(t, map[string]string{"hash": "ABCD"}, vars)
+	_, ok = p.match("/cosmos/tx/v1beta1/txs/ABCD/more")
+	require.False(t, ok)
+	_, ok = p.match("/cosmos/tx/v1beta1/txs/")
+	require.False(t, ok)
+
+	p, err = parsePattern("/ibc/apps/transfer/v1/denom_traces/{hash=**}")
+	require.NoError(t, err)
+	vars, ok = p.match("/ibc/apps/transfer/v1/denom_traces/transfer/channel-0/uatom")
+	require.True(t, ok)
+	require.Equal(t, "transfer/channel-0/uatom", vars["hash"])
+
+	p, err = parsePattern("/cosmos/tx/v1beta1/simulate:run")
+	require.NoError(t, err)
+	_, ok = p.match("/cosmos/tx/v1beta1/simulate:run")
+	require.True(t, ok)
+}
+
+func TestSetField_WellKnownTypes(t *testing.T) {
+	proposal := new(govv1beta1.Proposal)
+	require.NoError(t, setField(proposal.ProtoReflect(), "submit_time", []string{"2022-01-01T00:00:01Z"}))
+	require.Equal(t, int64(1640995201), proposal.SubmitTime.Seconds)
+
+	params := new(govv1beta1.VotingParams)
+	require.NoError(t, setField(params.ProtoReflect(), "voting_period", []string{"1.5s"}))
+	require.Equal(t, 1500*time.Millisecond, params.VotingPeriod.AsDuration())
+	require.Error(t, setField(params.ProtoReflect(), "voting_period", []string{"1.5"}))
+
+	// other messages can't be set from parameters
+	require.Error(t, setField(proposal.ProtoReflect(), "content", []string{"{}"}))
+}
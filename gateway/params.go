@@ -0,0 +1,138 @@
+package gateway
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// setField sets the field of msg identified by the dotted path, ex: pagination.limit,
+// parsing values. Fields can be referenced using their proto or JSON names.
+func setField(msg protoreflect.Message, path string, values []string) error {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = msg.Descriptor().Fields().ByJSONName(name)
+		}
+		if fd == nil {
+			return fmt.Errorf("%s has no field %s", msg.Descriptor().FullName(), name)
+		}
+
+		if i < len(names)-1 {
+			if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+				return fmt.Errorf("field %s is not a message", fd.FullName())
+			}
+			msg = msg.Mutable(fd).Message()
+			continue
+		}
+
+		switch {
+		case fd.IsMap():
+			return fmt.Errorf("map field %s cannot be set from parameters", fd.FullName())
+		case fd.IsList():
+			list := msg.Mutable(fd).List()
+			for _, v := range values {
+				value, err := parseValue(fd, v, list.NewElement)
+				if err != nil {
+					return err
+				}
+				list.Append(value)
+			}
+		default:
+			if len(values) != 1 {
+				return fmt.Errorf("field %s expects a single value", fd.FullName())
+			}
+			value, err := parseValue(fd, values[0], func() protoreflect.Value { return msg.NewField(fd) })
+			if err != nil {
+				return err
+			}
+			msg.Set(fd, value)
+		}
+	}
+	return nil
+}
+
+// parseValue parses the value of a scalar field, or of a Timestamp or Duration field using
+// their JSON formats, ex: 2022-01-01T00:00:00Z and 1.5s. newValue returns an empty message.
+func parseValue(fd protoreflect.FieldDescriptor, s string, newValue func() protoreflect.Value) (protoreflect.Value, error) {
+	var (
+		v   protoreflect.Value
+		err error
+	)
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		var b []byte
+		b, err = decodeBytes(s)
+		v = protoreflect.ValueOfBytes(b)
+	case protoreflect.BoolKind:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		v = protoreflect.ValueOfBool(b)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		var i int64
+		i, err = strconv.ParseInt(s, 10, 32)
+		v = protoreflect.ValueOfInt32(int32(i))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		var i int64
+		i, err = strconv.ParseInt(s, 10, 64)
+		v = protoreflect.ValueOfInt64(i)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		var u uint64
+		u, err = strconv.ParseUint(s, 10, 32)
+		v = protoreflect.ValueOfUint32(uint32(u))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		var u uint64
+		u, err = strconv.ParseUint(s, 10, 64)
+		v = protoreflect.ValueOfUint64(u)
+	case protoreflect.FloatKind:
+		var f float64
+		f, err = strconv.ParseFloat(s, 32)
+		v = protoreflect.ValueOfFloat32(float32(f))
+	case protoreflect.DoubleKind:
+		var f float64
+		f, err = strconv.ParseFloat(s, 64)
+		v = protoreflect.ValueOfFloat64(f)
+	case protoreflect.MessageKind:
+		switch fd.Message().FullName() {
+		case "google.protobuf.Timestamp", "google.protobuf.Duration":
+		default:
+			return protoreflect.Value{}, fmt.Errorf("field %s of type %s cannot be set from parameters", fd.FullName(), fd.Message().FullName())
+		}
+		var b []byte
+		b, err = json.Marshal(s)
+		if err == nil {
+			v = newValue()
+			err = protojson.Unmarshal(b, v.Message().Interface())
+		}
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		var i int64
+		i, err = strconv.ParseInt(s, 10, 32)
+		v = protoreflect.ValueOfEnum(protoreflect.EnumNumber(i))
+	default:
+		return protoreflect.Value{}, fmt.Errorf("field %s of kind %s cannot be set from parameters", fd.FullName(), fd.Kind())
+	}
+	if err != nil {
+		return protoreflect.Value{}, fmt.Errorf("invalid value %q for field %s: %w", s, fd.FullName(), err)
+	}
+	return v, nil
+}
+
+// decodeBytes decodes base64 values, accepting both the standard and URL encodings, padded or not.
+func decodeBytes(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
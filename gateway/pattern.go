@@ -0,0 +1,122 @@
+package gateway
+
+import (
+	"fmt"
+	"strings"
+)
+
+// pattern is a google.api.http path template, ex: /cosmos/bank/v1beta1/balances/{address}.
+type pattern struct {
+	template string
+	segments []segment
+	// verb is the custom verb suffix of the last segment, ex: :simulate.
+	verb string
+}
+
+type segment struct {
+	literal string
+	// field is the request field bound to the segment, set for variables.
+	field string
+	// multi reports if the variable matches the remaining segments, as in {name=**}.
+	multi bool
+}
+
+func parsePattern(template string) (*pattern, error) {
+	if !strings.HasPrefix(template, "/") {
+		return nil, fmt.Errorf("invalid path template %q: it must start with /", template)
+	}
+	p := &pattern{template: template}
+	path := template[1:]
+	// the verb follows the last segment, which is not a variable containing a colon
+	if i := strings.LastIndexByte(path, ':'); i >= 0 && !strings.Contains(path[i:], "}") {
+		path, p.verb = path[:i], path[i+1:]
+	}
+
+	for _, s := range splitSegments(path) {
+		if !strings.HasPrefix(s, "{") {
+			p.segments = append(p.segments, segment{literal: s})
+			continue
+		}
+		if !strings.HasSuffix(s, "}") {
+			return nil, fmt.Errorf("invalid path template %q: unterminated variable %s", template, s)
+		}
+		field, sub := s[1:len(s)-1], "*"
+		if i := strings.IndexByte(field, '='); i >= 0 {
+			field, sub = field[:i], field[i+1:]
+		}
+		switch sub {
+		case "*":
+			p.segments = append(p.segments, segment{field: field})
+		case "**":
+			p.segments = append(p.segments, segment{field: field, multi: true})
+		default:
+			return nil, fmt.Errorf("invalid path template %q: unsupported variable %s", template, s)
+		}
+	}
+	return p, nil
+}
+
+// splitSegments splits path by slashes outside of variables.
+func splitSegments(path string) []string {
+	var (
+		segments []string
+		depth    int
+		start    int
+	)
+	for i, r := range path {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '/':
+			if depth == 0 {
+				segments = append(segments, path[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(segments, path[start:])
+}
+
+// match matches the request path, returning the values of the variables.
+func (p *pattern) match(path string) (map[string]string, bool) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, false
+	}
+	path = path[1:]
+	if p.verb != "" {
+		if !strings.HasSuffix(path, ":"+p.verb) {
+			return nil, false
+		}
+		path = strings.TrimSuffix(path, ":"+p.verb)
+	}
+
+	parts := strings.Split(path, "/")
+	vars := map[string]string{}
+	for i, s := range p.segments {
+		if s.multi {
+			if i >= len(parts) {
+				return nil, false
+			}
+			vars[s.field] = strings.Join(parts[i:], "/")
+			return vars, true
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		switch {
+		case s.field != "":
+			if parts[i] == "" {
+				return nil, false
+			}
+			vars[s.field] = parts[i]
+		case s.literal != parts[i]:
+			return nil, false
+		}
+	}
+	if len(parts) != len(p.segments) {
+		return nil, false
+	}
+	return vars, true
+}
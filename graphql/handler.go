@@ -0,0 +1,88 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	gql "github.com/graphql-go/graphql"
+)
+
+// maxBodySize is the maximum size of request bodies.
+const maxBodySize = 1 << 20
+
+// Request is a GraphQL request.
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// Do executes the request.
+func (s *Server) Do(ctx context.Context, req Request) *gql.Result {
+	return gql.Do(gql.Params{
+		Schema:         s.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+}
+
+// ServeHTTP serves GraphQL requests sent using GET, with the query, variables and operationName
+// query parameters, or POST, with a JSON body or, if the content type is application/graphql,
+// with the query as body.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := readRequest(r)
+	if err != nil {
+		code := http.StatusBadRequest
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			code = http.StatusMethodNotAllowed
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	b, err := json.Marshal(s.Do(r.Context(), req))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+func readRequest(r *http.Request) (Request, error) {
+	var req Request
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				return req, fmt.Errorf("invalid variables: %w", err)
+			}
+		}
+	case http.MethodPost:
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+		if err != nil {
+			return req, err
+		}
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/graphql" {
+			req.Query = string(body)
+			break
+		}
+		if err = json.Unmarshal(body, &req); err != nil {
+			return req, fmt.Errorf("invalid request: %w", err)
+		}
+	default:
+		return req, fmt.Errorf("unsupported method %s", r.Method)
+	}
+	if req.Query == "" {
+		return req, fmt.Errorf("missing query")
+	}
+	return req, nil
+}
@@ -0,0 +1,406 @@
+// Package graphql exposes the query services discovered by a dynamic.Client as a GraphQL schema.
+package graphql
+
+import (
+	"fmt"
+	"strings"
+
+	dynamic "github.com/fdymylja/dynamic-cosmos"
+	"github.com/fdymylja/dynamic-cosmos/codec"
+	"github.com/fdymylja/dynamic-cosmos/protoutil"
+	gql "github.com/graphql-go/graphql"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	anyName       protoreflect.FullName = "google.protobuf.Any"
+	timestampName protoreflect.FullName = "google.protobuf.Timestamp"
+	durationName  protoreflect.FullName = "google.protobuf.Duration"
+
+	// emptyField is the placeholder field of types built from messages without fields,
+	// as GraphQL objects and input objects must define at least one field.
+	emptyField = "_empty"
+)
+
+// Server serves the GraphQL schema of the query services of a Client.
+//
+// Every unary query method is a field of the root Query type, named after the method
+// full name with dots replaced by underscores, ex: cosmos_bank_v1beta1_Query_AllBalances,
+// accepting the request as the request argument and the queried height as the height argument.
+//
+// Messages are mapped to object types, and to input object types suffixed with Input, named
+// after their full name. Fields use the protobuf JSON names, 64 bit integers and bytes are
+// mapped to the Int64, Uint64 and Bytes scalars, encoded as strings, and timestamps and durations
+// to strings in their JSON format. Maps are lists of their entries, and oneofs are exposed as
+// enum fields reporting the member which is set. Any fields are unions of the known implementations
+// of the interface they accept, as declared in the app codec descriptor, including google_protobuf_Any
+// which is used for values of unknown types.
+type Server struct {
+	client *dynamic.Client
+	schema gql.Schema
+}
+
+// New builds the GraphQL schema of the query services of the client catalog.
+// Msgs are included in the schema as object types.
+func New(client *dynamic.Client) (*Server, error) {
+	catalog, err := client.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	b := newBuilder(client)
+	fields := gql.Fields{}
+	for _, svc := range catalog.QueryServices() {
+		for _, method := range svc.Methods {
+			if method.Descriptor.IsStreamingClient() || method.Descriptor.IsStreamingServer() {
+				continue
+			}
+			fields[typeName(method.Descriptor.FullName())] = b.method(method)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("chain has no unary query methods")
+	}
+
+	var types []gql.Type
+	for _, msg := range catalog.Msgs() {
+		types = append(types, b.object(msg.Descriptor))
+	}
+
+	schema, err := gql.NewSchema(gql.SchemaConfig{
+		Query: gql.NewObject(gql.ObjectConfig{Name: "Query", Fields: fields}),
+		Types: types,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to build schema: %w", err)
+	}
+	return &Server{client: client, schema: schema}, nil
+}
+
+// Schema returns the GraphQL schema.
+func (s *Server) Schema() gql.Schema {
+	return s.schema
+}
+
+// builder builds the GraphQL types of the protobuf descriptors, caching them by name.
+type builder struct {
+	client   *dynamic.Client
+	registry *codec.Registry
+
+	objects map[protoreflect.FullName]*gql.Object
+	inputs  map[protoreflect.FullName]*gql.InputObject
+	enums   map[protoreflect.FullName]*gql.Enum
+	oneofs  map[protoreflect.FullName]*gql.Enum
+	unions  map[string]*gql.Union
+	anyObj  *gql.Object
+	anyIn   *gql.InputObject
+
+	// interfaces maps the Any fields to the interface they accept.
+	interfaces map[protoreflect.FullName]string
+	// implementers maps the interfaces to the full names of their implementations.
+	implementers map[string][]protoreflect.FullName
+}
+
+func newBuilder(client *dynamic.Client) *builder {
+	b := &builder{
+		client:       client,
+		registry:     client.Codec.Registry,
+		objects:      map[protoreflect.FullName]*gql.Object{},
+		inputs:       map[protoreflect.FullName]*gql.InputObject{},
+		enums:        map[protoreflect.FullName]*gql.Enum{},
+		oneofs:       map[protoreflect.FullName]*gql.Enum{},
+		unions:       map[string]*gql.Union{},
+		interfaces:   map[protoreflect.FullName]string{},
+		implementers: map[string][]protoreflect.FullName{},
+	}
+	for _, iface := range client.App.GetCodec().GetInterfaces() {
+		for _, impl := range iface.InterfaceImplementers {
+			b.implementers[iface.Fullname] = append(b.implementers[iface.Fullname], protoutil.FullNameFromURL(impl.TypeUrl))
+		}
+		for _, accepting := range iface.InterfaceAcceptingMessages {
+			for _, field := range accepting.FieldDescriptorNames {
+				b.interfaces[protoreflect.FullName(accepting.Fullname).Append(protoreflect.Name(field))] = iface.Fullname
+			}
+		}
+	}
+	return b
+}
+
+// method returns the root field of the query method.
+func (b *builder) method(method *dynamic.QueryMethod) *gql.Field {
+	args := gql.FieldConfigArgument{
+		"height": {Type: int64Scalar, Description: "The height to query, defaults to the latest."},
+	}
+	if method.Request.Fields().Len() != 0 {
+		args["request"] = &gql.ArgumentConfig{Type: b.input(method.Request)}
+	}
+	return &gql.Field{
+		Type:        b.object(method.Response),
+		Args:        args,
+		Description: fmt.Sprintf("Queries %s.", method.Path),
+		Resolve: func(p gql.ResolveParams) (interface{}, error) {
+			req := dynamicpb.NewMessage(method.Request)
+			if in, ok := p.Args["request"].(map[string]interface{}); ok {
+				err := b.setMessage(req, in)
+				if err != nil {
+					return nil, err
+				}
+			}
+			var opts []dynamic.QueryOption
+			if height, ok := p.Args["height"].(int64); ok {
+				opts = append(opts, dynamic.AtHeight(height))
+			}
+			resp := dynamicpb.NewMessage(method.Response)
+			err := b.client.DynamicQuery(p.Context, method.Path, req, resp, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return resp.ProtoReflect(), nil
+		},
+	}
+}
+
+// object returns the object type of the message.
+func (b *builder) object(md protoreflect.MessageDescriptor) *gql.Object {
+	if obj, ok := b.objects[md.FullName()]; ok {
+		return obj
+	}
+	// fields are built lazily, as messages can be recursive
+	obj := gql.NewObject(gql.ObjectConfig{
+		Name: typeName(md.FullName()),
+		Fields: (gql.FieldsThunk)(func() gql.Fields {
+			fields := gql.Fields{}
+			for i := 0; i < md.Fields().Len(); i++ {
+				fd := md.Fields().Get(i)
+				fields[fd.JSONName()] = &gql.Field{Type: b.outputType(fd), Resolve: b.resolveField(fd)}
+			}
+			for i := 0; i < md.Oneofs().Len(); i++ {
+				od := md.Oneofs().Get(i)
+				if od.IsSynthetic() {
+					continue
+				}
+				fields[string(od.Name())] = &gql.Field{Type: b.oneof(od), Resolve: resolveOneof(od)}
+			}
+			if len(fields) == 0 {
+				fields[emptyField] = &gql.Field{Type: gql.Boolean, Resolve: func(gql.ResolveParams) (interface{}, error) { return nil, nil }}
+			}
+			return fields
+		}),
+	})
+	b.objects[md.FullName()] = obj
+	return obj
+}
+
+// input returns the input object type of the message.
+func (b *builder) input(md protoreflect.MessageDescriptor) *gql.InputObject {
+	if in, ok := b.inputs[md.FullName()]; ok {
+		return in
+	}
+	in := gql.NewInputObject(gql.InputObjectConfig{
+		Name: typeName(md.FullName()) + "Input",
+		Fields: (gql.InputObjectConfigFieldMapThunk)(func() gql.InputObjectConfigFieldMap {
+			fields := gql.InputObjectConfigFieldMap{}
+			for i := 0; i < md.Fields().Len(); i++ {
+				fd := md.Fields().Get(i)
+				fields[fd.JSONName()] = &gql.InputObjectFieldConfig{Type: b.inputType(fd)}
+			}
+			if len(fields) == 0 {
+				fields[emptyField] = &gql.InputObjectFieldConfig{Type: gql.Boolean}
+			}
+			return fields
+		}),
+	})
+	b.inputs[md.FullName()] = in
+	return in
+}
+
+// outputType returns the type of the field of an object.
+func (b *builder) outputType(fd protoreflect.FieldDescriptor) gql.Output {
+	var typ gql.Output
+	switch {
+	case fd.IsMap():
+		return gql.NewList(b.object(fd.Message()))
+	case fd.Kind() == protoreflect.EnumKind:
+		typ = b.enum(fd.Enum())
+	case fd.Message() != nil:
+		switch fd.Message().FullName() {
+		case anyName:
+			typ = b.union(fd)
+		case timestampName, durationName:
+			typ = gql.String
+		default:
+			typ = b.object(fd.Message())
+		}
+	default:
+		typ = scalarType(fd.Kind())
+	}
+	if fd.IsList() {
+		return gql.NewList(typ)
+	}
+	return typ
+}
+
+// inputType returns the type of the field of an input object.
+func (b *builder) inputType(fd protoreflect.FieldDescriptor) gql.Input {
+	var typ gql.Input
+	switch {
+	case fd.IsMap():
+		return gql.NewList(b.input(fd.Message()))
+	case fd.Kind() == protoreflect.EnumKind:
+		typ = b.enum(fd.Enum())
+	case fd.Message() != nil:
+		switch fd.Message().FullName() {
+		case anyName:
+			typ = b.anyInput()
+		case timestampName, durationName:
+			typ = gql.String
+		default:
+			typ = b.input(fd.Message())
+		}
+	default:
+		typ = scalarType(fd.Kind())
+	}
+	if fd.IsList() {
+		return gql.NewList(typ)
+	}
+	return typ
+}
+
+// enum returns the enum type of the protobuf enum.
+func (b *builder) enum(ed protoreflect.EnumDescriptor) *gql.Enum {
+	if enum, ok := b.enums[ed.FullName()]; ok {
+		return enum
+	}
+	values := gql.EnumValueConfigMap{}
+	for i := 0; i < ed.Values().Len(); i++ {
+		v := ed.Values().Get(i)
+		values[string(v.Name())] = &gql.EnumValueConfig{Value: v.Number()}
+	}
+	enum := gql.NewEnum(gql.EnumConfig{Name: typeName(ed.FullName()), Values: values})
+	b.enums[ed.FullName()] = enum
+	return enum
+}
+
+// oneof returns the enum type listing the members of the oneof.
+func (b *builder) oneof(od protoreflect.OneofDescriptor) *gql.Enum {
+	if enum, ok := b.oneofs[od.FullName()]; ok {
+		return enum
+	}
+	values := gql.EnumValueConfigMap{}
+	for i := 0; i < od.Fields().Len(); i++ {
+		name := string(od.Fields().Get(i).Name())
+		values[name] = &gql.EnumValueConfig{Value: name}
+	}
+	enum := gql.NewEnum(gql.EnumConfig{Name: typeName(od.FullName()) + "Case", Values: values})
+	b.oneofs[od.FullName()] = enum
+	return enum
+}
+
+// union returns the union of the implementations of the interface accepted by the Any field,
+// or of every known implementation if the field does not declare it.
+func (b *builder) union(fd protoreflect.FieldDescriptor) *gql.Union {
+	iface := b.interfaces[fd.FullName()]
+	if union, ok := b.unions[iface]; ok {
+		return union
+	}
+
+	var impls []protoreflect.FullName
+	if iface != "" {
+		impls = b.implementers[iface]
+	} else {
+		seen := map[protoreflect.FullName]bool{}
+		for _, names := range b.implementers {
+			for _, name := range names {
+				if !seen[name] {
+					seen[name] = true
+					impls = append(impls, name)
+				}
+			}
+		}
+	}
+
+	name, description := "AnyValue", "The known implementations of every interface."
+	if iface != "" {
+		name, description = typeName(protoreflect.FullName(iface)), fmt.Sprintf("The known implementations of %s.", iface)
+	}
+	members := map[protoreflect.FullName]*gql.Object{}
+	union := gql.NewUnion(gql.UnionConfig{
+		Name:        name,
+		Description: description,
+		Types: (gql.UnionTypesThunk)(func() []*gql.Object {
+			types := []*gql.Object{b.anyObject()}
+			for _, impl := range impls {
+				typ, err := b.registry.FindMessageByName(impl)
+				// implementations which cannot be resolved are served as google_protobuf_Any
+				if err != nil || members[impl] != nil || isSpecial(impl) {
+					continue
+				}
+				members[impl] = b.object(typ.Descriptor())
+				types = append(types, members[impl])
+			}
+			return types
+		}),
+		ResolveType: func(p gql.ResolveTypeParams) *gql.Object {
+			if v, ok := p.Value.(anyValue); ok && v.msg != nil {
+				if obj, ok := members[v.msg.Descriptor().FullName()]; ok {
+					return obj
+				}
+			}
+			return b.anyObject()
+		},
+	})
+	b.unions[iface] = union
+	return union
+}
+
+// anyObject returns the object type of Any values of unknown types.
+func (b *builder) anyObject() *gql.Object {
+	if b.anyObj != nil {
+		return b.anyObj
+	}
+	b.anyObj = gql.NewObject(gql.ObjectConfig{
+		Name: typeName(anyName),
+		Fields: gql.Fields{
+			"typeUrl": &gql.Field{Type: gql.String, Resolve: resolveAny(func(v anyValue) interface{} {
+				return v.any.Get(v.any.Descriptor().Fields().ByName("type_url")).String()
+			})},
+			"value": &gql.Field{Type: bytesScalar, Resolve: resolveAny(func(v anyValue) interface{} {
+				return v.any.Get(v.any.Descriptor().Fields().ByName("value")).Bytes()
+			})},
+		},
+	})
+	return b.anyObj
+}
+
+// anyInput returns the input object type of Any values, which are set from either
+// the protobuf encoded value or from the JSON encoding of the message.
+func (b *builder) anyInput() *gql.InputObject {
+	if b.anyIn != nil {
+		return b.anyIn
+	}
+	b.anyIn = gql.NewInputObject(gql.InputObjectConfig{
+		Name: typeName(anyName) + "Input",
+		Fields: gql.InputObjectConfigFieldMap{
+			"typeUrl": &gql.InputObjectFieldConfig{Type: gql.NewNonNull(gql.String)},
+			"value":   &gql.InputObjectFieldConfig{Type: bytesScalar},
+			"json":    &gql.InputObjectFieldConfig{Type: gql.String, Description: "The JSON encoded message, used if value is not set."},
+		},
+	})
+	return b.anyIn
+}
+
+// isSpecial reports if the message is mapped to a scalar, and cannot be a member of a union.
+func isSpecial(name protoreflect.FullName) bool {
+	return name == anyName || name == timestampName || name == durationName
+}
+
+// typeName returns the GraphQL name of a protobuf full name, ex: cosmos_bank_v1beta1_Query.
+func typeName(name protoreflect.FullName) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, string(name))
+}
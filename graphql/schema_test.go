@@ -0,0 +1,120 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	authv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/auth/v1beta1"
+	bankv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/bank/v1beta1"
+	reflectionv2alpha1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/reflection/v2alpha1"
+	basev1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/v1beta1"
+	"github.com/fdymylja/dynamic-cosmos/internal/testutil/testclient"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+type bank struct {
+	bankv1beta1.UnimplementedQueryServer
+}
+
+func (bank) AllBalances(ctx context.Context, req *bankv1beta1.QueryAllBalancesRequest) (*bankv1beta1.QueryAllBalancesResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	resp := &bankv1beta1.QueryAllBalancesResponse{}
+	for i := uint64(0); i < req.Pagination.GetLimit(); i++ {
+		resp.Balances = append(resp.Balances, &basev1beta1.Coin{
+			Denom:  req.Address + strconv.FormatUint(i, 10),
+			Amount: strings.Join(md.Get("x-cosmos-block-height"), ""),
+		})
+	}
+	return resp, nil
+}
+
+type auth struct {
+	authv1beta1.UnimplementedQueryServer
+}
+
+func (auth) Account(_ context.Context, req *authv1beta1.QueryAccountRequest) (*authv1beta1.QueryAccountResponse, error) {
+	account, err := anypb.New(&authv1beta1.BaseAccount{Address: req.Address, AccountNumber: 1 << 40})
+	if err != nil {
+		return nil, err
+	}
+	return &authv1beta1.QueryAccountResponse{Account: account}, nil
+}
+
+func newTestServer(t *testing.T) *Server {
+	c := testclient.Dial(t, &reflectionv2alpha1.AppDescriptor{
+		Chain:         &reflectionv2alpha1.ChainDescriptor{Id: "test-1"},
+		Configuration: &reflectionv2alpha1.ConfigurationDescriptor{Bech32AccountAddressPrefix: "cosmos"},
+		Codec: &reflectionv2alpha1.CodecDescriptor{Interfaces: []*reflectionv2alpha1.InterfaceDescriptor{{
+			Fullname: "cosmos.auth.v1beta1.AccountI",
+			InterfaceAcceptingMessages: []*reflectionv2alpha1.InterfaceAcceptingMessageDescriptor{
+				{Fullname: "cosmos.auth.v1beta1.QueryAccountResponse", FieldDescriptorNames: []string{"account"}},
+			},
+			InterfaceImplementers: []*reflectionv2alpha1.InterfaceImplementerDescriptor{
+				{Fullname: "cosmos.auth.v1beta1.BaseAccount", TypeUrl: "/cosmos.auth.v1beta1.BaseAccount"},
+			},
+		}}},
+		QueryServices: &reflectionv2alpha1.QueryServicesDescriptor{QueryServices: []*reflectionv2alpha1.QueryServiceDescriptor{
+			{Fullname: "cosmos.bank.v1beta1.Query", IsModule: true},
+			{Fullname: "cosmos.auth.v1beta1.Query", IsModule: true},
+		}},
+		Tx: &reflectionv2alpha1.TxDescriptor{},
+	}, func(srv *grpc.Server) {
+		bankv1beta1.RegisterQueryServer(srv, &bank{})
+		authv1beta1.RegisterQueryServer(srv, &auth{})
+	})
+
+	s, err := New(c)
+	require.NoError(t, err)
+	return s
+}
+
+func TestServer(t *testing.T) {
+	s := newTestServer(t)
+
+	// nested inputs, 64 bit scalars and height
+	res := s.Do(context.Background(), Request{
+		Query: `query($limit: Uint64) {
+			cosmos_bank_v1beta1_Query_AllBalances(height: "5", request: {address: "addr", pagination: {limit: $limit}}) {
+				balances { denom amount }
+			}
+		}`,
+		Variables: map[string]interface{}{"limit": "2"},
+	})
+	require.Empty(t, res.Errors)
+	b, err := json.Marshal(res.Data)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"cosmos_bank_v1beta1_Query_AllBalances":{"balances":[
+		{"denom":"addr0","amount":"5"},{"denom":"addr1","amount":"5"}
+	]}}`, string(b))
+
+	// Any fields are unions of the interface implementations
+	query := `{
+		cosmos_auth_v1beta1_Query_Account(request: {address: "addr"}) {
+			account { __typename ... on cosmos_auth_v1beta1_BaseAccount { address accountNumber } }
+		}
+	}`
+	body, err := json.Marshal(Request{Query: query})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body))))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"data":{"cosmos_auth_v1beta1_Query_Account":{"account":{
+		"__typename":"cosmos_auth_v1beta1_BaseAccount","address":"addr","accountNumber":"1099511627776"
+	}}}}`, w.Body.String())
+
+	// validation errors
+	res = s.Do(context.Background(), Request{Query: `{ cosmos_bank_v1beta1_Query_AllBalances(request: {unknown: 1}) { balances { denom } } }`})
+	require.NotEmpty(t, res.Errors)
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
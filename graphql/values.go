@@ -0,0 +1,458 @@
+package graphql
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var (
+	int64Scalar = gql.NewScalar(gql.ScalarConfig{
+		Name:        "Int64",
+		Description: "A signed 64 bit integer, serialized as a string.",
+		Serialize: func(v interface{}) interface{} {
+			if i, ok := v.(int64); ok {
+				return strconv.FormatInt(i, 10)
+			}
+			return nil
+		},
+		ParseValue: func(v interface{}) interface{} {
+			return parseInteger(v, true)
+		},
+		ParseLiteral: func(v ast.Value) interface{} {
+			return parseInteger(literal(v), true)
+		},
+	})
+	uint64Scalar = gql.NewScalar(gql.ScalarConfig{
+		Name:        "Uint64",
+		Description: "An unsigned 64 bit integer, serialized as a string.",
+		Serialize: func(v interface{}) interface{} {
+			if u, ok := v.(uint64); ok {
+				return strconv.FormatUint(u, 10)
+			}
+			return nil
+		},
+		ParseValue: func(v interface{}) interface{} {
+			return parseInteger(v, false)
+		},
+		ParseLiteral: func(v ast.Value) interface{} {
+			return parseInteger(literal(v), false)
+		},
+	})
+	bytesScalar = gql.NewScalar(gql.ScalarConfig{
+		Name:        "Bytes",
+		Description: "Bytes, serialized using the standard base64 encoding.",
+		Serialize: func(v interface{}) interface{} {
+			if b, ok := v.([]byte); ok {
+				return base64.StdEncoding.EncodeToString(b)
+			}
+			return nil
+		},
+		ParseValue: func(v interface{}) interface{} {
+			return parseBytes(v)
+		},
+		ParseLiteral: func(v ast.Value) interface{} {
+			return parseBytes(literal(v))
+		},
+	})
+)
+
+// scalarType returns the GraphQL type of the scalar kind.
+func scalarType(kind protoreflect.Kind) gql.Type {
+	switch kind {
+	case protoreflect.BoolKind:
+		return gql.Boolean
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return gql.Int
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return int64Scalar
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return uint64Scalar
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return gql.Float
+	case protoreflect.BytesKind:
+		return bytesScalar
+	default:
+		return gql.String
+	}
+}
+
+// literal returns the value of int and string literals.
+func literal(v ast.Value) interface{} {
+	switch v := v.(type) {
+	case *ast.IntValue:
+		return v.Value
+	case *ast.StringValue:
+		return v.Value
+	default:
+		return nil
+	}
+}
+
+// parseInteger parses 64 bit integers provided as strings or numbers, returning nil if invalid.
+func parseInteger(v interface{}, signed bool) interface{} {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case int:
+		s = strconv.Itoa(v)
+	case float64:
+		// variables are decoded as float64, which is exact up to 2^53
+		if v != math.Trunc(v) {
+			return nil
+		}
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return nil
+	}
+	if signed {
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil
+		}
+		return i
+	}
+	u, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+// parseBytes decodes base64 strings, accepting both the standard and URL encodings, padded or not.
+func parseBytes(v interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	s = strings.TrimRight(s, "=")
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(s, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+	b, err := encoding.DecodeString(s)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// anyValue is the value of an Any field, and msg is the unpacked message, or nil if its type is unknown.
+type anyValue struct {
+	any protoreflect.Message
+	msg protoreflect.Message
+}
+
+// sourceMessage returns the message resolved by the parent field.
+func sourceMessage(source interface{}) protoreflect.Message {
+	switch v := source.(type) {
+	case protoreflect.Message:
+		return v
+	case anyValue:
+		return v.msg
+	default:
+		return nil
+	}
+}
+
+// resolveField resolves the value of the field of the source message.
+func (b *builder) resolveField(fd protoreflect.FieldDescriptor) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		msg := sourceMessage(p.Source)
+		if msg == nil {
+			return nil, nil
+		}
+
+		switch {
+		case fd.IsMap():
+			return mapEntries(fd, msg.Get(fd).Map()), nil
+		case fd.IsList():
+			list := msg.Get(fd).List()
+			values := make([]interface{}, list.Len())
+			for i := range values {
+				v, err := b.outputValue(fd, list.Get(i))
+				if err != nil {
+					return nil, err
+				}
+				values[i] = v
+			}
+			return values, nil
+		case fd.Message() != nil && !msg.Has(fd):
+			return nil, nil
+		default:
+			return b.outputValue(fd, msg.Get(fd))
+		}
+	}
+}
+
+// outputValue converts a value of the field to the representation expected by its GraphQL type.
+func (b *builder) outputValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) (interface{}, error) {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		return v.Enum(), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		msg := v.Message()
+		switch msg.Descriptor().FullName() {
+		case anyName:
+			return b.unpack(msg), nil
+		case timestampName, durationName:
+			j, err := protojson.Marshal(msg.Interface())
+			if err != nil {
+				return nil, err
+			}
+			var s string
+			return s, json.Unmarshal(j, &s)
+		default:
+			return msg, nil
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return int(v.Int()), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return v.Int(), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return v.Uint(), nil
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float(), nil
+	default:
+		return v.Interface(), nil
+	}
+}
+
+// unpack unpacks the Any message, leaving the message nil if its type cannot be resolved.
+func (b *builder) unpack(any protoreflect.Message) anyValue {
+	fields := any.Descriptor().Fields()
+	v := anyValue{any: any}
+	typ, err := b.registry.FindMessageByURL(any.Get(fields.ByName("type_url")).String())
+	if err != nil {
+		return v
+	}
+	msg := typ.New()
+	if err = b.client.Codec.UnmarshalProto(any.Get(fields.ByName("value")).Bytes(), msg.Interface()); err != nil {
+		return v
+	}
+	v.msg = msg
+	return v
+}
+
+// mapEntries returns the entries of the map as messages of the map entry type, sorted by key.
+func mapEntries(fd protoreflect.FieldDescriptor, m protoreflect.Map) []interface{} {
+	var keys []protoreflect.MapKey
+	m.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+
+	entries := make([]interface{}, len(keys))
+	for i, k := range keys {
+		entry := dynamicpb.NewMessage(fd.Message())
+		entry.Set(fd.MapKey(), k.Value())
+		entry.Set(fd.MapValue(), m.Get(k))
+		entries[i] = entry.ProtoReflect()
+	}
+	return entries
+}
+
+// resolveOneof resolves the name of the member of the oneof which is set.
+func resolveOneof(od protoreflect.OneofDescriptor) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		msg := sourceMessage(p.Source)
+		if msg == nil {
+			return nil, nil
+		}
+		fd := msg.WhichOneof(od)
+		if fd == nil {
+			return nil, nil
+		}
+		return string(fd.Name()), nil
+	}
+}
+
+// resolveAny resolves a field of google_protobuf_Any.
+func resolveAny(fn func(v anyValue) interface{}) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		v, ok := p.Source.(anyValue)
+		if !ok {
+			return nil, nil
+		}
+		return fn(v), nil
+	}
+}
+
+// setMessage sets the fields of msg from the input object value.
+func (b *builder) setMessage(msg protoreflect.Message, in map[string]interface{}) error {
+	fields := msg.Descriptor().Fields()
+	for name, v := range in {
+		if v == nil || name == emptyField {
+			continue
+		}
+		fd := fields.ByJSONName(name)
+		if fd == nil {
+			return fmt.Errorf("%s has no field %s", msg.Descriptor().FullName(), name)
+		}
+		if od := fd.ContainingOneof(); od != nil && !od.IsSynthetic() && msg.WhichOneof(od) != nil {
+			return fmt.Errorf("multiple fields of oneof %s are set", od.FullName())
+		}
+
+		switch {
+		case fd.IsMap():
+			m := msg.Mutable(fd).Map()
+			for _, e := range toList(v) {
+				in, ok := e.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("invalid entry for field %s", fd.FullName())
+				}
+				entry := dynamicpb.NewMessage(fd.Message())
+				if err := b.setMessage(entry, in); err != nil {
+					return err
+				}
+				value := entry.Get(fd.MapValue())
+				if fd.MapValue().Message() != nil {
+					value = entry.Mutable(fd.MapValue())
+				}
+				m.Set(entry.Get(fd.MapKey()).MapKey(), value)
+			}
+		case fd.IsList():
+			list := msg.Mutable(fd).List()
+			for _, item := range toList(v) {
+				value, err := b.inputValue(fd, item, list.NewElement)
+				if err != nil {
+					return err
+				}
+				list.Append(value)
+			}
+		default:
+			value, err := b.inputValue(fd, v, func() protoreflect.Value { return msg.NewField(fd) })
+			if err != nil {
+				return err
+			}
+			msg.Set(fd, value)
+		}
+	}
+	return nil
+}
+
+func toList(v interface{}) []interface{} {
+	if list, ok := v.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{v}
+}
+
+// inputValue converts the input value of the field, newValue returns an empty message value.
+func (b *builder) inputValue(fd protoreflect.FieldDescriptor, v interface{}, newValue func() protoreflect.Value) (protoreflect.Value, error) {
+	var (
+		value protoreflect.Value
+		ok    bool
+	)
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		var n protoreflect.EnumNumber
+		n, ok = v.(protoreflect.EnumNumber)
+		value = protoreflect.ValueOfEnum(n)
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		value = newValue()
+		var err error
+		switch fd.Message().FullName() {
+		case timestampName, durationName:
+			var s string
+			if s, ok = v.(string); ok {
+				var j []byte
+				j, err = json.Marshal(s)
+				if err == nil {
+					err = protojson.Unmarshal(j, value.Message().Interface())
+				}
+			}
+		case anyName:
+			var in map[string]interface{}
+			if in, ok = v.(map[string]interface{}); ok {
+				err = b.setAny(value.Message(), in)
+			}
+		default:
+			var in map[string]interface{}
+			if in, ok = v.(map[string]interface{}); ok {
+				err = b.setMessage(value.Message(), in)
+			}
+		}
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid value for field %s: %w", fd.FullName(), err)
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		var i int
+		i, ok = v.(int)
+		value = protoreflect.ValueOfInt32(int32(i))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		var i int64
+		i, ok = v.(int64)
+		value = protoreflect.ValueOfInt64(i)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		var u uint64
+		u, ok = v.(uint64)
+		ok = ok && u <= math.MaxUint32
+		value = protoreflect.ValueOfUint32(uint32(u))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		var u uint64
+		u, ok = v.(uint64)
+		value = protoreflect.ValueOfUint64(u)
+	case protoreflect.FloatKind:
+		var f float64
+		f, ok = v.(float64)
+		value = protoreflect.ValueOfFloat32(float32(f))
+	case protoreflect.DoubleKind:
+		var f float64
+		f, ok = v.(float64)
+		value = protoreflect.ValueOfFloat64(f)
+	case protoreflect.BoolKind:
+		var bl bool
+		bl, ok = v.(bool)
+		value = protoreflect.ValueOfBool(bl)
+	case protoreflect.StringKind:
+		var s string
+		s, ok = v.(string)
+		value = protoreflect.ValueOfString(s)
+	case protoreflect.BytesKind:
+		var bz []byte
+		bz, ok = v.([]byte)
+		value = protoreflect.ValueOfBytes(bz)
+	}
+	if !ok {
+		return protoreflect.Value{}, fmt.Errorf("invalid value %v for field %s", v, fd.FullName())
+	}
+	return value, nil
+}
+
+// setAny sets the Any message from the input, packing the JSON encoded message if the value is not provided.
+func (b *builder) setAny(any protoreflect.Message, in map[string]interface{}) error {
+	typeURL, _ := in["typeUrl"].(string)
+	value, _ := in["value"].([]byte)
+	if j, ok := in["json"].(string); ok && value == nil {
+		typ, err := b.registry.FindMessageByURL(typeURL)
+		if err != nil {
+			return err
+		}
+		msg := typ.New().Interface()
+		if err = b.client.Codec.UnmarshalProtoJSON([]byte(j), msg); err != nil {
+			return err
+		}
+		if value, err = b.client.Codec.MarshalProto(msg); err != nil {
+			return err
+		}
+	}
+	fields := any.Descriptor().Fields()
+	any.Set(fields.ByName("type_url"), protoreflect.ValueOfString(typeURL))
+	any.Set(fields.ByName("value"), protoreflect.ValueOfBytes(value))
+	return nil
+}
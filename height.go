@@ -0,0 +1,174 @@
+package dynamic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// blockHeightHeader is the gRPC metadata key used by cosmos-sdk nodes
+// to select the queried height and to report the served one.
+const blockHeightHeader = "x-cosmos-block-height"
+
+// ErrHeightUnavailable is matched by the errors returned when the node
+// cannot serve the state at the requested height.
+var ErrHeightUnavailable = errors.New("height not available")
+
+// HeightUnavailableError is returned when the node cannot serve the state at
+// Height, because it was pruned or it is greater than the latest block height.
+type HeightUnavailableError struct {
+	Height int64
+	Err    error
+}
+
+func (e *HeightUnavailableError) Error() string {
+	return fmt.Sprintf("state at height %d is not available on the node, it might have been pruned: %s", e.Height, e.Err)
+}
+
+func (e *HeightUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+func (e *HeightUnavailableError) Is(target error) bool {
+	return target == ErrHeightUnavailable
+}
+
+// QueryOption configures a query.
+type QueryOption func(*queryOptions)
+
+type queryOptions struct {
+	height       int64
+	servedHeight *int64
+}
+
+// AtHeight queries the state at the given height instead of the latest one.
+func AtHeight(height int64) QueryOption {
+	return func(o *queryOptions) {
+		o.height = height
+	}
+}
+
+// ServedHeight sets height to the height the node served the query from.
+// It is left unchanged if the node does not report it.
+func ServedHeight(height *int64) QueryOption {
+	return func(o *queryOptions) {
+		o.servedHeight = height
+	}
+}
+
+func newQueryOptions(opts []QueryOption) *queryOptions {
+	o := new(queryOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// invokeQuery invokes the query method on conn, pinning the height and reporting the served one.
+func invokeQuery(ctx context.Context, conn grpc.ClientConnInterface, method string, req, resp interface{}, o *queryOptions, callOpts ...grpc.CallOption) error {
+	if o.height < 0 {
+		return fmt.Errorf("invalid height %d", o.height)
+	}
+	if o.height > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, blockHeightHeader, strconv.FormatInt(o.height, 10))
+	}
+
+	var header metadata.MD
+	err := conn.Invoke(ctx, method, req, resp, append(callOpts, grpc.Header(&header))...)
+	if err != nil {
+		if o.height > 0 && isHeightUnavailable(err) {
+			return &HeightUnavailableError{Height: o.height, Err: err}
+		}
+		return err
+	}
+
+	values := header.Get(blockHeightHeader)
+	if len(values) == 0 {
+		return nil
+	}
+	served, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s header %q: %w", blockHeightHeader, values[0], err)
+	}
+	if o.height > 0 && served != o.height {
+		return fmt.Errorf("requested height %d but the node served height %d", o.height, served)
+	}
+	if o.servedHeight != nil {
+		*o.servedHeight = served
+	}
+	return nil
+}
+
+// isHeightUnavailable reports if err is the one returned by cosmos-sdk nodes
+// when the state at the requested height cannot be loaded.
+func isHeightUnavailable(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "failed to load state at height") ||
+		strings.Contains(msg, "version does not exist") ||
+		strings.Contains(msg, "cannot query with height in the future")
+}
+
+// HeightClient queries the chain state at a fixed height, it is created using Client.AtHeight.
+type HeightClient struct {
+	client *Client
+	height int64
+}
+
+// AtHeight returns a view of the Client which queries the state at the given height,
+// so that multiple queries read a consistent snapshot.
+func (c *Client) AtHeight(height int64) *HeightClient {
+	return &HeightClient{client: c, height: height}
+}
+
+// Height returns the height the queries are pinned to.
+func (h *HeightClient) Height() int64 {
+	return h.height
+}
+
+// DynamicQuery is like Client.DynamicQuery, at the pinned height.
+func (h *HeightClient) DynamicQuery(ctx context.Context, method string, req, resp proto.Message, opts ...QueryOption) error {
+	return h.client.DynamicQuery(ctx, method, req, resp, h.queryOptions(opts)...)
+}
+
+// QueryJSON is like Client.QueryJSON, at the pinned height.
+func (h *HeightClient) QueryJSON(ctx context.Context, method string, jsonReq []byte, opts ...QueryOption) ([]byte, error) {
+	return h.client.QueryJSON(ctx, method, jsonReq, h.queryOptions(opts)...)
+}
+
+// Paginate is like Client.Paginate, at the pinned height.
+func (h *HeightClient) Paginate(ctx context.Context, method string, req proto.Message, opts ...PaginateOption) (*Paginator, error) {
+	return h.client.Paginate(ctx, method, req, append(opts, WithQueryOptions(AtHeight(h.height)))...)
+}
+
+// ClientConn returns a connection which queries the state at the pinned height,
+// which can be used with generated gRPC query clients.
+func (h *HeightClient) ClientConn() grpc.ClientConnInterface {
+	return heightConn{ClientConnInterface: h.client.grpc, height: h.height}
+}
+
+func (h *HeightClient) queryOptions(opts []QueryOption) []QueryOption {
+	return append(opts, AtHeight(h.height))
+}
+
+var _ grpc.ClientConnInterface = heightConn{}
+
+// heightConn is a grpc.ClientConnInterface which pins the queried height.
+type heightConn struct {
+	grpc.ClientConnInterface
+	height int64
+}
+
+func (c heightConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	return invokeQuery(ctx, c.ClientConnInterface, method, args, reply, &queryOptions{height: c.height}, opts...)
+}
+
+func (c heightConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, blockHeightHeader, strconv.FormatInt(c.height, 10))
+	return c.ClientConnInterface.NewStream(ctx, desc, method, opts...)
+}
@@ -0,0 +1,75 @@
+package dynamic
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	bankv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/bank/v1beta1"
+	basev1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/v1beta1"
+	"github.com/fdymylja/dynamic-cosmos/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// historicalBank serves balances equal to the queried height, keeping only the recent ones.
+type historicalBank struct {
+	bankv1beta1.UnimplementedQueryServer
+}
+
+func (historicalBank) Balance(ctx context.Context, req *bankv1beta1.QueryBalanceRequest) (*bankv1beta1.QueryBalanceResponse, error) {
+	const latest, pruned = 100, 50
+	height := int64(latest)
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(blockHeightHeader); len(values) != 0 {
+		height, _ = strconv.ParseInt(values[0], 10, 64)
+	}
+	if height <= pruned {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to load state at height %d; version does not exist (latest height: %d): invalid request", height, latest)
+	}
+	err := grpc.SetHeader(ctx, metadata.Pairs(blockHeightHeader, strconv.FormatInt(height, 10)))
+	if err != nil {
+		return nil, err
+	}
+	return &bankv1beta1.QueryBalanceResponse{Balance: &basev1beta1.Coin{Denom: req.Denom, Amount: fmt.Sprint(height)}}, nil
+}
+
+func TestClient_AtHeight(t *testing.T) {
+	conn := testutil.NewConn(t, func(srv *grpc.Server) {
+		bankv1beta1.RegisterQueryServer(srv, &historicalBank{})
+	})
+
+	c, err := DialConn(context.Background(), conn, "", WithAppDescriptor(testAppDescriptor()))
+	require.NoError(t, err)
+	defer c.Close()
+
+	const method = "/cosmos.bank.v1beta1.Query/Balance"
+	req := &bankv1beta1.QueryBalanceRequest{Address: "cosmos1", Denom: "stake"}
+
+	// latest
+	var served int64
+	resp := new(bankv1beta1.QueryBalanceResponse)
+	require.NoError(t, c.DynamicQuery(context.Background(), method, req, resp, ServedHeight(&served)))
+	require.Equal(t, int64(100), served)
+	require.Equal(t, "100", resp.Balance.Amount)
+
+	// pinned view, also through generated clients
+	view := c.AtHeight(70)
+	require.NoError(t, view.DynamicQuery(context.Background(), method, req, resp, ServedHeight(&served)))
+	require.Equal(t, int64(70), served)
+	resp, err = bankv1beta1.NewQueryClient(view.ClientConn()).Balance(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, "70", resp.Balance.Amount)
+
+	// pruned
+	err = c.DynamicQuery(context.Background(), method, req, resp, AtHeight(10))
+	require.ErrorIs(t, err, ErrHeightUnavailable)
+	var heightErr *HeightUnavailableError
+	require.ErrorAs(t, err, &heightErr)
+	require.Equal(t, int64(10), heightErr.Height)
+	require.Equal(t, codes.InvalidArgument, status.Code(heightErr.Err))
+}
@@ -0,0 +1,24 @@
+// Package testclient dials Clients to in-memory test servers, it's separate from
+// testutil so that the tests of the dynamic package can use testutil.
+package testclient
+
+import (
+	"context"
+	"testing"
+
+	reflectionv2alpha1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/reflection/v2alpha1"
+	dynamic "github.com/fdymylja/dynamic-cosmos"
+	"github.com/fdymylja/dynamic-cosmos/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// Dial returns a Client using the app descriptor, connected to
+// an in-memory gRPC server exposing the services registered by register.
+func Dial(t *testing.T, desc *reflectionv2alpha1.AppDescriptor, register func(srv *grpc.Server)) *dynamic.Client {
+	conn := testutil.NewConn(t, register)
+	c, err := dynamic.DialConn(context.Background(), conn, "", dynamic.WithAppDescriptor(desc))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
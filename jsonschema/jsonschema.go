@@ -0,0 +1,244 @@
+// Package jsonschema generates JSON Schema definitions of protobuf messages, describing
+// their protojson encoding.
+package jsonschema
+
+import (
+	"github.com/fdymylja/dynamic-cosmos/codec"
+	"github.com/fdymylja/dynamic-cosmos/protoutil"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Draft is the JSON Schema dialect of the generated documents.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema.
+type Schema struct {
+	Schema          string             `json:"$schema,omitempty"`
+	Ref             string             `json:"$ref,omitempty"`
+	Title           string             `json:"title,omitempty"`
+	Description     string             `json:"description,omitempty"`
+	Type            string             `json:"type,omitempty"`
+	Format          string             `json:"format,omitempty"`
+	Pattern         string             `json:"pattern,omitempty"`
+	ContentEncoding string             `json:"contentEncoding,omitempty"`
+	Enum            []interface{}      `json:"enum,omitempty"`
+	Items           *Schema            `json:"items,omitempty"`
+	Properties      map[string]*Schema `json:"properties,omitempty"`
+	// AdditionalProperties is the schema of the values of map fields, an empty schema accepts any value.
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Option configures a Generator.
+type Option func(g *Generator)
+
+// WithRefPrefix sets the prefix of the references to the definitions, defaults to #/$defs/.
+func WithRefPrefix(prefix string) Option {
+	return func(g *Generator) {
+		g.refPrefix = prefix
+	}
+}
+
+// WithProtoNames sets if properties use the protobuf field names
+// instead of the lowerCamelCase JSON names.
+func WithProtoNames(protoNames bool) Option {
+	return func(g *Generator) {
+		g.protoNames = protoNames
+	}
+}
+
+// WithEnumNumbers sets if enums are described as numbers instead of names.
+func WithEnumNumbers(numbers bool) Option {
+	return func(g *Generator) {
+		g.enumNumbers = numbers
+	}
+}
+
+// WithCodec describes the JSON encoding of the codec, using its field names and enum encoding.
+func WithCodec(c *codec.Codec) Option {
+	return func(g *Generator) {
+		opts := c.ProtoOptions().JSONMarshal
+		g.protoNames, g.enumNumbers = opts.UseProtoNames, opts.UseEnumNumbers
+	}
+}
+
+// Generator generates the JSON Schema definitions of messages, named after their full names.
+// Messages are referenced using $ref, so that recursive messages can be described.
+type Generator struct {
+	refPrefix   string
+	protoNames  bool
+	enumNumbers bool
+
+	defs map[string]*Schema
+}
+
+// NewGenerator returns a Generator.
+func NewGenerator(opts ...Option) *Generator {
+	g := &Generator{refPrefix: "#/$defs/", defs: map[string]*Schema{}}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Generate returns a JSON Schema document describing the message.
+func Generate(md protoreflect.MessageDescriptor, opts ...Option) *Schema {
+	g := NewGenerator(opts...)
+	schema := g.Message(md)
+	schema.Schema, schema.Defs = Draft, g.Definitions()
+	return schema
+}
+
+// Message returns the schema of the message, which is a reference to its definition
+// unless the message is a well known type with a special JSON mapping.
+func (g *Generator) Message(md protoreflect.MessageDescriptor) *Schema {
+	if schema, ok := wellKnownSchema(md); ok {
+		return schema
+	}
+
+	name := string(md.FullName())
+	if _, ok := g.defs[name]; !ok {
+		def := &Schema{
+			Title:       name,
+			Description: protoutil.Comments(md),
+			Type:        "object",
+			Properties:  map[string]*Schema{},
+		}
+		// the definition is added before its fields, as they can reference it
+		g.defs[name] = def
+		for i := 0; i < md.Fields().Len(); i++ {
+			fd := md.Fields().Get(i)
+			def.Properties[g.fieldName(fd)] = g.Field(fd)
+		}
+	}
+	return &Schema{Ref: g.refPrefix + name}
+}
+
+// Field returns the schema of the value of the field.
+func (g *Generator) Field(fd protoreflect.FieldDescriptor) *Schema {
+	var schema *Schema
+	switch {
+	case fd.IsMap():
+		// map keys are always encoded as strings
+		schema = &Schema{Type: "object", AdditionalProperties: g.singular(fd.MapValue())}
+	case fd.IsList():
+		schema = &Schema{Type: "array", Items: g.singular(fd)}
+	default:
+		schema = g.singular(fd)
+	}
+	if description := protoutil.Comments(fd); description != "" {
+		// $ref siblings are allowed since draft 2019-09
+		schema.Description = description
+	}
+	return schema
+}
+
+// Definitions returns the definitions of the messages generated so far, by full name.
+func (g *Generator) Definitions() map[string]*Schema {
+	defs := make(map[string]*Schema, len(g.defs))
+	for name, def := range g.defs {
+		defs[name] = def
+	}
+	return defs
+}
+
+func (g *Generator) fieldName(fd protoreflect.FieldDescriptor) string {
+	if g.protoNames {
+		return string(fd.Name())
+	}
+	return fd.JSONName()
+}
+
+// singular returns the schema of a single value of the field.
+func (g *Generator) singular(fd protoreflect.FieldDescriptor) *Schema {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return g.Message(fd.Message())
+	case protoreflect.EnumKind:
+		return g.enum(fd.Enum())
+	default:
+		return scalarSchema(fd.Kind())
+	}
+}
+
+func (g *Generator) enum(ed protoreflect.EnumDescriptor) *Schema {
+	if ed.FullName() == "google.protobuf.NullValue" {
+		return &Schema{Type: "null"}
+	}
+	schema := &Schema{Title: string(ed.FullName()), Description: protoutil.Comments(ed)}
+	values := ed.Values()
+	for i := 0; i < values.Len(); i++ {
+		if g.enumNumbers {
+			schema.Enum = append(schema.Enum, values.Get(i).Number())
+		} else {
+			schema.Enum = append(schema.Enum, string(values.Get(i).Name()))
+		}
+	}
+	if g.enumNumbers {
+		schema.Type = "integer"
+	} else {
+		schema.Type = "string"
+	}
+	return schema
+}
+
+// scalarSchema returns the schema of the scalar kind, 64 bit integers are encoded as strings.
+func scalarSchema(kind protoreflect.Kind) *Schema {
+	switch kind {
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "uint32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return &Schema{Type: "string", Format: "int64", Pattern: `^-?[0-9]+$`}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Type: "string", Format: "uint64", Pattern: `^[0-9]+$`}
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte", ContentEncoding: "base64"}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// wellKnownSchema returns the schema of the well known types with a special JSON mapping.
+func wellKnownSchema(md protoreflect.MessageDescriptor) (*Schema, bool) {
+	switch md.FullName() {
+	case "google.protobuf.Any":
+		return &Schema{
+			Type:        "object",
+			Description: "A message of any type, identified by the @type URL, with its fields inlined or, for well known types, in the value property.",
+			Properties:  map[string]*Schema{"@type": {Type: "string"}},
+			Required:    []string{"@type"},
+			// the other properties depend on the type
+			AdditionalProperties: &Schema{},
+		}, true
+	case "google.protobuf.Timestamp":
+		return &Schema{Type: "string", Format: "date-time"}, true
+	case "google.protobuf.Duration":
+		return &Schema{Type: "string", Pattern: `^-?[0-9]+(\.[0-9]{1,9})?s$`}, true
+	case "google.protobuf.FieldMask":
+		return &Schema{Type: "string"}, true
+	case "google.protobuf.Struct":
+		return &Schema{Type: "object", AdditionalProperties: &Schema{}}, true
+	case "google.protobuf.Value":
+		return &Schema{}, true
+	case "google.protobuf.ListValue":
+		return &Schema{Type: "array", Items: &Schema{}}, true
+	case "google.protobuf.Empty":
+		return &Schema{Type: "object"}, true
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue", "google.protobuf.Int64Value",
+		"google.protobuf.UInt64Value", "google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue", "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		// wrappers are encoded as their value
+		return scalarSchema(md.Fields().ByName("value").Kind()), true
+	default:
+		return nil, false
+	}
+}
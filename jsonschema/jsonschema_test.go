@@ -0,0 +1,34 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	govv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/gov/v1beta1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	schema := Generate((&govv1beta1.Proposal{}).ProtoReflect().Descriptor())
+	require.Equal(t, Draft, schema.Schema)
+	require.Equal(t, "#/$defs/cosmos.gov.v1beta1.Proposal", schema.Ref)
+
+	def := schema.Defs["cosmos.gov.v1beta1.Proposal"]
+	require.NotNil(t, def)
+	require.Equal(t, &Schema{Type: "string", Format: "uint64", Pattern: `^[0-9]+$`}, def.Properties["proposalId"])
+	require.Equal(t, &Schema{Type: "string", Format: "date-time"}, def.Properties["submitTime"])
+	require.Equal(t, []string{"@type"}, def.Properties["content"].Required)
+	require.Equal(t, "string", def.Properties["status"].Type)
+	require.Contains(t, def.Properties["status"].Enum, "PROPOSAL_STATUS_PASSED")
+	require.Equal(t, "array", def.Properties["totalDeposit"].Type)
+	require.Equal(t, "#/$defs/cosmos.base.v1beta1.Coin", def.Properties["totalDeposit"].Items.Ref)
+	require.Contains(t, schema.Defs, "cosmos.base.v1beta1.Coin")
+
+	schema = Generate((&govv1beta1.Proposal{}).ProtoReflect().Descriptor(), WithProtoNames(true), WithEnumNumbers(true))
+	def = schema.Defs["cosmos.gov.v1beta1.Proposal"]
+	require.Contains(t, def.Properties, "proposal_id")
+	require.Equal(t, "integer", def.Properties["status"].Type)
+
+	_, err := json.Marshal(schema)
+	require.NoError(t, err)
+}
@@ -0,0 +1,184 @@
+package dynamic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fdymylja/dynamic-cosmos/tx"
+	tmmath "github.com/tendermint/tendermint/libs/math"
+	"github.com/tendermint/tendermint/light"
+	"github.com/tendermint/tendermint/light/provider"
+	lighthttp "github.com/tendermint/tendermint/light/provider/http"
+	lightdb "github.com/tendermint/tendermint/light/store/db"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	"github.com/tendermint/tendermint/types"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// LightClientConfig configures the light client embedded in the Client.
+type LightClientConfig struct {
+	// TrustedHeight and TrustedHash identify the header the light client is bootstrapped from,
+	// they are required unless a trusted state was already persisted in StoreDir.
+	TrustedHeight int64
+	TrustedHash   []byte
+	// TrustingPeriod is the period a trusted header can be used to verify new headers,
+	// it must be significantly less than the chain unbonding period.
+	TrustingPeriod time.Duration
+	// TrustLevel is the minimum fraction of the trusted validator set voting power which
+	// must sign a new header for skipping verification, defaults to 1/3.
+	TrustLevel tmmath.Fraction
+	// Witnesses are the tendermint RPC endpoints, other than the Client ones, used to cross check
+	// the headers of the primary, which is the first tendermint endpoint of the Client.
+	Witnesses []string
+	// StoreDir is the directory where the trusted state is persisted,
+	// if empty it is kept in memory.
+	StoreDir string
+}
+
+// WithLightClient verifies headers using an embedded light client, which is used to provide
+// the app hashes for Client.QueryStore, unless WithAppHashProvider is provided, and to verify
+// the inclusion of the txs found by the tx watcher. The tendermint endpoints of the Client
+// other than the first one are used as witnesses, and at least one witness is required.
+func WithLightClient(config LightClientConfig) DialOption {
+	return func(options *options) {
+		options.lightClient = &config
+	}
+}
+
+// LightClient verifies headers using skipping verification starting from a trusted header.
+type LightClient struct {
+	client *light.Client
+	db     dbm.DB
+}
+
+var _ AppHashProvider = (*LightClient)(nil)
+
+// newLightClient creates a LightClient verifying the headers of primary, cross checked with witnesses.
+func newLightClient(ctx context.Context, config LightClientConfig, chainID string, primary provider.Provider, witnesses []provider.Provider) (*LightClient, error) {
+	if len(witnesses) == 0 {
+		return nil, fmt.Errorf("light client requires at least one witness")
+	}
+	trustLevel := config.TrustLevel
+	if trustLevel.Denominator == 0 {
+		trustLevel = light.DefaultTrustLevel
+	}
+
+	var (
+		db  dbm.DB
+		err error
+	)
+	if config.StoreDir == "" {
+		db = dbm.NewMemDB()
+	} else {
+		db, err = dbm.NewGoLevelDB("light-client", config.StoreDir)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open light client store: %w", err)
+		}
+	}
+	store := lightdb.New(db, chainID)
+
+	var client *light.Client
+	// the trusted state is restored from the store if no trusted header is provided
+	if config.TrustedHeight == 0 && len(config.TrustedHash) == 0 {
+		client, err = light.NewClientFromTrustedStore(chainID, config.TrustingPeriod, primary, witnesses, store, light.SkippingVerification(trustLevel))
+	} else {
+		client, err = light.NewClient(ctx, chainID, light.TrustOptions{
+			Period: config.TrustingPeriod,
+			Height: config.TrustedHeight,
+			Hash:   config.TrustedHash,
+		}, primary, witnesses, store, light.SkippingVerification(trustLevel))
+	}
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("unable to create light client: %w", err)
+	}
+
+	return &LightClient{client: client, db: db}, nil
+}
+
+// dialLightClient creates a LightClient using tendermint RPC providers.
+func dialLightClient(ctx context.Context, config LightClientConfig, chainID string, tmEndpoints []string) (*LightClient, error) {
+	endpoints := append(append([]string{}, tmEndpoints...), config.Witnesses...)
+	providers := make([]provider.Provider, len(endpoints))
+	for i, endpoint := range endpoints {
+		p, err := lighthttp.New(chainID, endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create light client provider for %s: %w", endpoint, err)
+		}
+		providers[i] = p
+	}
+	return newLightClient(ctx, config, chainID, providers[0], providers[1:])
+}
+
+// VerifiedHeader returns the header at height, verifying it if it is not yet trusted.
+func (l *LightClient) VerifiedHeader(ctx context.Context, height int64) (*types.SignedHeader, error) {
+	lb, err := l.client.VerifyLightBlockAtHeight(ctx, height, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return lb.SignedHeader, nil
+}
+
+// AppHash returns the app hash committing to the state at height, from the verified header at height+1.
+func (l *LightClient) AppHash(ctx context.Context, height int64) ([]byte, error) {
+	header, err := l.VerifiedHeader(ctx, height+1)
+	if err != nil {
+		return nil, err
+	}
+	return header.AppHash, nil
+}
+
+// Update verifies the latest header of the primary, returning it
+// or nil if the trusted header is already the latest one.
+func (l *LightClient) Update(ctx context.Context) (*types.SignedHeader, error) {
+	lb, err := l.client.Update(ctx, time.Now())
+	if err != nil || lb == nil {
+		return nil, err
+	}
+	return lb.SignedHeader, nil
+}
+
+// LastTrustedHeight returns the height of the latest trusted header.
+func (l *LightClient) LastTrustedHeight() (int64, error) {
+	return l.client.LastTrustedHeight()
+}
+
+// VerifyTxInclusion verifies that txBytes were included in the block at height, using
+// the tx merkle proof provided by tm against the data hash of the verified header.
+func (l *LightClient) VerifyTxInclusion(ctx context.Context, tm rpcclient.Client, txBytes []byte, height int64) error {
+	res, err := tm.Tx(ctx, types.Tx(txBytes).Hash(), true)
+	if err != nil {
+		return fmt.Errorf("unable to fetch tx proof: %w", err)
+	}
+	if res.Height != height {
+		return fmt.Errorf("tx was included at height %d, expected %d", res.Height, height)
+	}
+	if !bytes.Equal(res.Proof.Data, txBytes) {
+		return fmt.Errorf("tx proof is for a different tx")
+	}
+
+	header, err := l.VerifiedHeader(ctx, height)
+	if err != nil {
+		return err
+	}
+	return res.Proof.Validate(header.DataHash)
+}
+
+// Close closes the trusted state store.
+func (l *LightClient) Close() error {
+	return l.db.Close()
+}
+
+// LightClient returns the light client set using WithLightClient, or nil.
+func (c *Client) LightClient() *LightClient {
+	return c.light
+}
+
+// txInclusionVerifier returns the tx.InclusionVerifier using the light client.
+func txInclusionVerifier(l *LightClient, tm *tmPool) tx.InclusionVerifier {
+	return func(ctx context.Context, resp *tx.Response) error {
+		return l.VerifyTxInclusion(ctx, tm.get(), resp.Bytes, resp.Block)
+	}
+}
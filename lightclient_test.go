@@ -0,0 +1,106 @@
+package dynamic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	"github.com/tendermint/tendermint/light/provider"
+	"github.com/tendermint/tendermint/light/provider/mock"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmversion "github.com/tendermint/tendermint/proto/tendermint/version"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tendermint/version"
+)
+
+// mockChain signs headers from 1 to n, with a single validator, block 2 includes txs.
+func mockChain(t *testing.T, chainID string, n int64, txs types.Txs) *mock.Mock {
+	pv := types.NewMockPV()
+	pubKey, err := pv.GetPubKey()
+	require.NoError(t, err)
+	vals := types.NewValidatorSet([]*types.Validator{types.NewValidator(pubKey, 10)})
+
+	headers := map[int64]*types.SignedHeader{}
+	valSets := map[int64]*types.ValidatorSet{}
+	start := time.Now().Add(-time.Hour)
+	for h := int64(1); h <= n; h++ {
+		header := &types.Header{
+			Version:            tmversion.Consensus{Block: version.BlockProtocol},
+			ChainID:            chainID,
+			Height:             h,
+			Time:               start.Add(time.Duration(h) * time.Minute),
+			ValidatorsHash:     vals.Hash(),
+			NextValidatorsHash: vals.Hash(),
+			AppHash:            tmhash.Sum([]byte{byte(h)}),
+			ProposerAddress:    vals.Proposer.Address,
+		}
+		if h == 2 {
+			header.DataHash = txs.Hash()
+		}
+		blockID := types.BlockID{Hash: header.Hash(), PartSetHeader: types.PartSetHeader{Total: 1, Hash: tmhash.Sum(nil)}}
+		voteSet := types.NewVoteSet(chainID, h, 0, tmproto.PrecommitType, vals)
+		commit, err := types.MakeCommit(blockID, h, 0, voteSet, []types.PrivValidator{pv}, header.Time)
+		require.NoError(t, err)
+
+		headers[h] = &types.SignedHeader{Header: header, Commit: commit}
+		valSets[h] = vals
+	}
+	valSets[n+1] = vals
+	return mock.New(chainID, headers, valSets)
+}
+
+// txClient serves the tx proofs.
+type txClient struct {
+	rpcclient.Client
+	res *ctypes.ResultTx
+}
+
+func (c txClient) Tx(context.Context, []byte, bool) (*ctypes.ResultTx, error) {
+	return c.res, nil
+}
+
+func TestLightClient(t *testing.T) {
+	const chainID = "test-1"
+	txs := types.Txs{types.Tx("tx0"), types.Tx("tx1")}
+	primary := mockChain(t, chainID, 3, txs)
+	witness := primary.Copy(chainID)
+	trusted, err := primary.LightBlock(context.Background(), 1)
+	require.NoError(t, err)
+
+	config := LightClientConfig{
+		TrustedHeight:  1,
+		TrustedHash:    trusted.Hash(),
+		TrustingPeriod: 24 * time.Hour,
+		StoreDir:       t.TempDir(),
+	}
+	lc, err := newLightClient(context.Background(), config, chainID, primary, []provider.Provider{witness})
+	require.NoError(t, err)
+
+	// the app hash of the state at height 2 is in the header at height 3
+	appHash, err := lc.AppHash(context.Background(), 2)
+	require.NoError(t, err)
+	require.Equal(t, tmhash.Sum([]byte{3}), appHash)
+
+	tm := txClient{res: &ctypes.ResultTx{Height: 2, Proof: txs.Proof(1)}}
+	require.NoError(t, lc.VerifyTxInclusion(context.Background(), tm, txs[1], 2))
+	require.Error(t, lc.VerifyTxInclusion(context.Background(), tm, txs[0], 2))
+	tm.res.Proof.Data = types.Tx("tx2")
+	require.Error(t, lc.VerifyTxInclusion(context.Background(), tm, tm.res.Proof.Data, 2))
+	require.NoError(t, lc.Close())
+
+	// the trusted state is restored from the store
+	config.TrustedHeight, config.TrustedHash = 0, nil
+	lc, err = newLightClient(context.Background(), config, chainID, primary, []provider.Provider{witness})
+	require.NoError(t, err)
+	defer lc.Close()
+	height, err := lc.LastTrustedHeight()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), height)
+
+	_, err = newLightClient(context.Background(), config, chainID, primary, nil)
+	require.ErrorContains(t, err, "at least one witness")
+}
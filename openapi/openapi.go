@@ -0,0 +1,318 @@
+// Package openapi generates OpenAPI documents describing the query services of a chain.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	dynamic "github.com/fdymylja/dynamic-cosmos"
+	"github.com/fdymylja/dynamic-cosmos/gateway"
+	"github.com/fdymylja/dynamic-cosmos/jsonschema"
+	"github.com/fdymylja/dynamic-cosmos/protoutil"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Version is the OpenAPI version of the generated documents, which
+// is the first one whose schemas are JSON Schema documents.
+const Version = "3.1.0"
+
+// heightHeader is the header selecting the queried height, the height
+// query parameter is bound to the request field with that name instead.
+const heightHeader = "x-cosmos-block-height"
+
+// maxQueryDepth is the maximum nesting of the messages flattened to query parameters.
+const maxQueryDepth = 3
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info is the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem lists the operations of a path.
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+	Patch  *Operation `json:"patch,omitempty"`
+}
+
+// Operation describes an API operation.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a path, query or header parameter.
+type Parameter struct {
+	Name        string             `json:"name"`
+	In          string             `json:"in"`
+	Description string             `json:"description,omitempty"`
+	Required    bool               `json:"required,omitempty"`
+	Schema      *jsonschema.Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType describes the content of a body.
+type MediaType struct {
+	Schema *jsonschema.Schema `json:"schema"`
+}
+
+// Components holds the schemas of the messages.
+type Components struct {
+	Schemas map[string]*jsonschema.Schema `json:"schemas"`
+}
+
+// Option configures the generated document.
+type Option func(o *options)
+
+type options struct {
+	info Info
+}
+
+// WithInfo sets the info of the document, which defaults to the chain ID as title.
+func WithInfo(info Info) Option {
+	return func(o *options) {
+		o.info = info
+	}
+}
+
+// varRegexp matches the variables of path templates, ex: {address} or {name=**}.
+var varRegexp = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+
+// errorSchema is the schema of the errors returned by the grpc-gateway.
+var errorSchema = &jsonschema.Schema{
+	Type: "object",
+	Properties: map[string]*jsonschema.Schema{
+		"code":    {Type: "integer", Format: "int32"},
+		"message": {Type: "string"},
+		"details": {Type: "array", Items: &jsonschema.Schema{Ref: "#/components/schemas/google.protobuf.Any"}},
+	},
+}
+
+// Generate returns the OpenAPI document of the query services of the client catalog.
+// Methods annotated with google.api.http are described on their annotated routes, as served
+// by the chain REST server or by the gateway package, the others on the generic POST route
+// of the gateway, ex: /cosmos.bank.v1beta1.Query/AllBalances. The schemas of every Msg of
+// the chain are included in the components, and follow the JSON encoding of the client codec.
+func Generate(client *dynamic.Client, opts ...Option) (*Document, error) {
+	o := &options{info: Info{Title: client.App.GetChain().GetId(), Version: "1.0.0"}}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	gw, err := gateway.New(client)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := client.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		schemas: jsonschema.NewGenerator(jsonschema.WithRefPrefix("#/components/schemas/"), jsonschema.WithCodec(client.Codec)),
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    o.info,
+			Paths:   map[string]*PathItem{},
+		},
+		operations: map[string]int{},
+	}
+
+	annotated := map[*dynamic.QueryMethod]bool{}
+	for _, route := range gw.Routes() {
+		annotated[route.Method] = true
+		err = g.addRoute(route)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, svc := range catalog.QueryServices() {
+		for _, method := range svc.Methods {
+			if annotated[method] || method.Descriptor.IsStreamingClient() || method.Descriptor.IsStreamingServer() {
+				continue
+			}
+			err = g.addRoute(gateway.Route{HTTPMethod: http.MethodPost, Pattern: method.Path, Body: "*", Method: method})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, msg := range catalog.Msgs() {
+		g.schemas.Message(msg.Descriptor)
+	}
+	g.doc.Components.Schemas = g.schemas.Definitions()
+	g.doc.Components.Schemas["google.protobuf.Any"] = g.schemas.Message((*anypb.Any)(nil).ProtoReflect().Descriptor())
+	g.doc.Components.Schemas["Status"] = errorSchema
+	return g.doc, nil
+}
+
+type generator struct {
+	schemas *jsonschema.Generator
+	doc     *Document
+	// operations counts the operations of each method, to make their IDs unique.
+	operations map[string]int
+}
+
+// addRoute adds the operation of the route.
+func (g *generator) addRoute(route gateway.Route) error {
+	method := route.Method
+	path := varRegexp.ReplaceAllString(route.Pattern, "{$1}")
+	item, ok := g.doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		g.doc.Paths[path] = item
+	}
+
+	id := string(method.Descriptor.FullName())
+	if n := g.operations[id]; n != 0 {
+		id = fmt.Sprintf("%s_%d", id, n)
+	}
+	g.operations[string(method.Descriptor.FullName())]++
+
+	op := &Operation{
+		OperationID: id,
+		Summary:     protoutil.Comments(method.Descriptor),
+		Tags:        []string{string(method.Service)},
+		Responses: map[string]*Response{
+			"200": {Description: "A successful response.", Content: jsonContent(g.schemas.Message(method.Response))},
+			"default": {Description: "An error response.", Content: jsonContent(&jsonschema.Schema{
+				Ref: "#/components/schemas/Status",
+			})},
+		},
+	}
+
+	bound := map[string]bool{}
+	for _, match := range varRegexp.FindAllStringSubmatch(route.Pattern, -1) {
+		fd, err := fieldByPath(method.Request, match[1])
+		if err != nil {
+			return fmt.Errorf("invalid route %s of %s: %w", route.Pattern, method.Descriptor.FullName(), err)
+		}
+		bound[match[1]] = true
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   g.schemas.Field(fd),
+		})
+	}
+
+	switch route.Body {
+	case "":
+	case "*":
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(g.schemas.Message(method.Request))}
+	default:
+		fd := method.Request.Fields().ByName(protoreflect.Name(route.Body))
+		if fd == nil {
+			return fmt.Errorf("invalid body field %s of %s", route.Body, method.Descriptor.FullName())
+		}
+		bound[route.Body] = true
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(g.schemas.Field(fd))}
+	}
+	// with the whole request as body the query parameters are not bound
+	if route.Body != "*" {
+		g.queryParameters(op, method.Request, "", bound, 0)
+	}
+	op.Parameters = append(op.Parameters, &Parameter{
+		Name:        heightHeader,
+		In:          "header",
+		Description: "The height to query, defaults to the latest.",
+		Schema:      &jsonschema.Schema{Type: "string", Format: "int64"},
+	})
+
+	switch route.HTTPMethod {
+	case http.MethodGet:
+		item.Get = op
+	case http.MethodPut:
+		item.Put = op
+	case http.MethodPost:
+		item.Post = op
+	case http.MethodDelete:
+		item.Delete = op
+	case http.MethodPatch:
+		item.Patch = op
+	}
+	return nil
+}
+
+// queryParameters adds the query parameters of the fields of md which are not bound, flattening
+// singular messages using dotted names, ex: pagination.limit. Timestamps and Durations are set
+// using their JSON formats, while maps and Anys cannot be set from query parameters and are skipped.
+func (g *generator) queryParameters(op *Operation, md protoreflect.MessageDescriptor, prefix string, bound map[string]bool, depth int) {
+	for i := 0; i < md.Fields().Len(); i++ {
+		fd := md.Fields().Get(i)
+		name := prefix + string(fd.Name())
+		if bound[name] || fd.IsMap() {
+			continue
+		}
+		if fd.Message() != nil {
+			switch fd.Message().FullName() {
+			case "google.protobuf.Timestamp", "google.protobuf.Duration":
+			default:
+				if !fd.IsList() && depth < maxQueryDepth && fd.Message().FullName() != "google.protobuf.Any" {
+					g.queryParameters(op, fd.Message(), name+".", bound, depth+1)
+				}
+				continue
+			}
+		}
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:   name,
+			In:     "query",
+			Schema: g.schemas.Field(fd),
+		})
+	}
+}
+
+// fieldByPath returns the field of md identified by the dotted path.
+func fieldByPath(md protoreflect.MessageDescriptor, path string) (protoreflect.FieldDescriptor, error) {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, fmt.Errorf("%s has no field %s", md.FullName(), name)
+		}
+		if i == len(names)-1 {
+			return fd, nil
+		}
+		if fd.Message() == nil || fd.IsList() {
+			return nil, fmt.Errorf("field %s is not a message", fd.FullName())
+		}
+		md = fd.Message()
+	}
+	return nil, fmt.Errorf("empty field path")
+}
+
+func jsonContent(schema *jsonschema.Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
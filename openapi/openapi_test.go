@@ -0,0 +1,56 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	bankv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/bank/v1beta1"
+	reflectionv2alpha1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/reflection/v2alpha1"
+	"github.com/fdymylja/dynamic-cosmos/internal/testutil/testclient"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestGenerate(t *testing.T) {
+	c := testclient.Dial(t, &reflectionv2alpha1.AppDescriptor{
+		Chain:         &reflectionv2alpha1.ChainDescriptor{Id: "test-1"},
+		Configuration: &reflectionv2alpha1.ConfigurationDescriptor{Bech32AccountAddressPrefix: "cosmos"},
+		QueryServices: &reflectionv2alpha1.QueryServicesDescriptor{QueryServices: []*reflectionv2alpha1.QueryServiceDescriptor{
+			{Fullname: "cosmos.bank.v1beta1.Query", IsModule: true},
+			{Fullname: "grpc.reflection.v1alpha.ServerReflection"},
+		}},
+		Tx: &reflectionv2alpha1.TxDescriptor{},
+	}, func(srv *grpc.Server) {
+		bankv1beta1.RegisterQueryServer(srv, &bankv1beta1.UnimplementedQueryServer{})
+	})
+
+	doc, err := Generate(c)
+	require.NoError(t, err)
+	require.Equal(t, Version, doc.OpenAPI)
+	require.Equal(t, "test-1", doc.Info.Title)
+
+	item := doc.Paths["/cosmos/bank/v1beta1/balances/{address}"]
+	require.NotNil(t, item)
+	require.NotNil(t, item.Get)
+	require.Equal(t, "cosmos.bank.v1beta1.Query.AllBalances", item.Get.OperationID)
+	params := map[string]*Parameter{}
+	for _, p := range item.Get.Parameters {
+		params[p.In+":"+p.Name] = p
+	}
+	require.True(t, params["path:address"].Required)
+	require.Equal(t, "string", params["query:pagination.limit"].Schema.Type)
+	require.Equal(t, "byte", params["query:pagination.key"].Schema.Format)
+	require.Contains(t, params, "header:"+heightHeader)
+	require.Equal(t, "#/components/schemas/cosmos.bank.v1beta1.QueryAllBalancesResponse",
+		item.Get.Responses["200"].Content["application/json"].Schema.Ref)
+	require.Contains(t, doc.Components.Schemas, "cosmos.bank.v1beta1.QueryAllBalancesResponse")
+	require.Contains(t, doc.Components.Schemas, "cosmos.base.query.v1beta1.PageResponse")
+
+	// streaming methods are not described
+	for path := range doc.Paths {
+		require.NotContains(t, path, "ServerReflection")
+	}
+
+	_, err = json.Marshal(doc)
+	require.NoError(t, err)
+}
@@ -3,24 +3,29 @@ package dynamic
 import (
 	"context"
 	"fmt"
+	"io"
+	"time"
 
 	txv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/tx/v1beta1"
 	"github.com/fdymylja/dynamic-cosmos/tx"
 
 	reflectionv2alpha1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/reflection/v2alpha1"
+	"github.com/fdymylja/dynamic-cosmos/chainregistry"
 	"github.com/fdymylja/dynamic-cosmos/codec"
 	"github.com/fdymylja/dynamic-cosmos/protoutil"
-	"github.com/tendermint/tendermint/rpc/client/http"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
-func newOptions(grpcEndpoint, tmEndpoint string) *options {
+func newOptions(grpcEndpoints, tmEndpoints []string) *options {
 	return &options{
-		grpcEndpoint:       grpcEndpoint,
-		tendermintEndpoint: tmEndpoint,
+		grpcEndpoints:       grpcEndpoints,
+		tendermintEndpoints: tmEndpoints,
+		healthInterval:      defaultHealthCheckInterval,
+		maxBlockLag:         defaultMaxBlockLag,
 		auth: &authenticationOptions{
 			signer:             nil,
 			signerInfoProvider: nil,
@@ -31,52 +36,110 @@ func newOptions(grpcEndpoint, tmEndpoint string) *options {
 
 // options defines the options of a client
 type options struct {
-	grpcEndpoint       string
-	tendermintEndpoint string
-
-	appDesc *reflectionv2alpha1.AppDescriptor
-	remote  codec.ProtoFileRegistry
-	auth    *authenticationOptions
+	grpcEndpoints       []string
+	tendermintEndpoints []string
+	healthInterval      time.Duration
+	maxBlockLag         int64
+
+	bech32Prefix     string
+	chainRegistryDir string
+	chain            *chainregistry.Chain
+	gasPrice         *GasPrice
+
+	appDesc   *reflectionv2alpha1.AppDescriptor
+	remote    codec.ProtoFileRegistry
+	codecOpts []codec.Option
+	auth      *authenticationOptions
+
+	lazyCatalog bool
+	appHashes   AppHashProvider
+	lightClient *LightClientConfig
+
+	transportCreds credentials.TransportCredentials
+	grpcOpts       []grpc.DialOption
 }
 
-// setup sets up the *Client
+// setup dials the grpc endpoints and sets up the *Client
 func (o *options) setup(ctx context.Context) (*Client, error) {
-	if o.tendermintEndpoint == "" {
-		return nil, fmt.Errorf("no tendermint endpoint set")
+	var (
+		conn     grpc.ClientConnInterface
+		closer   io.Closer
+		balanced *balancedConn
+	)
+	if o.healthInterval <= 0 {
+		return nil, fmt.Errorf("invalid health check interval %s, it must be positive", o.healthInterval)
+	}
+	if o.maxBlockLag < 0 {
+		return nil, fmt.Errorf("invalid max block lag %d, it must not be negative", o.maxBlockLag)
 	}
-	if o.grpcEndpoint == "" {
+	switch len(o.grpcEndpoints) {
+	case 0:
 		return nil, fmt.Errorf("no grpc endpoint set")
+	case 1:
+		grpcConn, err := grpc.DialContext(ctx, o.grpcEndpoints[0], o.grpcDialOptions()...)
+		if err != nil {
+			return nil, err
+		}
+		conn, closer = grpcConn, grpcConn
+	default:
+		var err error
+		balanced, err = dialBalancedConn(ctx, o.grpcEndpoints, o.maxBlockLag, o.grpcDialOptions()...)
+		if err != nil {
+			return nil, err
+		}
+		conn, closer = balanced, balanced
 	}
 
+	client, err := o.setupConn(ctx, conn)
+	if err != nil {
+		_ = closer.Close()
+		return nil, err
+	}
+	client.connCloser = closer
+
+	// health check endpoints only if there's more than one to choose from
+	if balanced != nil || len(o.tendermintEndpoints) > 1 {
+		client.health = startHealthMonitor(o.healthInterval, balanced, client.tm, client.watcher)
+	}
+	return client, nil
+}
+
+// setupConn sets up the *Client using an existing grpc connection
+func (o *options) setupConn(ctx context.Context, grpcConn grpc.ClientConnInterface) (_ *Client, err error) {
 	// we check if remote is set, if it's not set we default
-	// to the grpc registry remote
+	// to the grpc registry remote sharing the connection
 	if o.remote == nil {
-		remote, err := codec.NewGRPCReflectionProtoFileRegistry(o.grpcEndpoint)
+		o.remote = codec.NewGRPCReflectionProtoFileRegistryFromConn(grpcConn)
+	}
+
+	// on failure, what was set up is released in reverse order, like Client.Close does
+	var cleanup []func()
+	defer func() {
 		if err != nil {
-			return nil, fmt.Errorf("unable to set up grpc remote protofile registry: %w", err)
+			for i := len(cleanup) - 1; i >= 0; i-- {
+				cleanup[i]()
+			}
 		}
-		o.remote = remote
-	}
+	}()
+	cleanup = append(cleanup, func() { _ = o.remote.Close() })
 
 	// setup codec
-	cdc := codec.NewCodec(o.remote)
-
-	// dial grpc connection
-	conn, err := grpc.DialContext(ctx, o.grpcEndpoint,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithDefaultCallOptions(grpc.ForceCodec(cdc.GRPCCodec())))
-	if err != nil {
-		return nil, err
-	}
+	cdc := codec.NewCodec(o.remote, o.codecOpts...)
+	conn := codecConn{ClientConnInterface: grpcConn, codec: cdc.GRPCCodec()}
 
 	// we need to fetch the app descriptor if it was not set
 	if o.appDesc == nil {
-		err = o.setAppDesc(ctx, conn)
+		err = o.setAppDesc(ctx, conn, cdc.Registry)
 		if err != nil {
 			return nil, fmt.Errorf("unable to setup app descriptor: %w", err)
 		}
 	}
 
+	err = o.checkChain()
+	if err != nil {
+		return nil, err
+	}
+
 	// setup addresses
 	addresses := o.addresses(o.appDesc.Configuration)
 
@@ -86,38 +149,76 @@ func (o *options) setup(ctx context.Context) (*Client, error) {
 		return nil, fmt.Errorf("unable to setup authentication options: %w", err)
 	}
 
-	// set up tendermint
-	tm, err := http.New(o.tendermintEndpoint, "/websocket")
-	if err != nil {
-		return nil, err
-	}
-	err = tm.Start()
-	if err != nil {
-		return nil, err
+	// set up tendermint, without an endpoint the client is query only
+	var (
+		tm          *tmPool
+		lightClient *LightClient
+		txWatcher   *tx.Watcher
+	)
+	if o.lightClient != nil && len(o.tendermintEndpoints) == 0 {
+		return nil, fmt.Errorf("light client requires a tendermint endpoint")
 	}
+	if len(o.tendermintEndpoints) != 0 {
+		tm, err = newTMPool(o.tendermintEndpoints, o.maxBlockLag)
+		if err != nil {
+			return nil, err
+		}
+		cleanup = append(cleanup, func() { _ = tm.stop() })
+		started, err := tm.start(ctx)
+		if err != nil {
+			return nil, err
+		}
 
-	txWatcher, err := tx.DialWatcher(ctx, tm)
-	if err != nil {
-		return nil, err
+		var watcherOpts []tx.WatcherOption
+		if o.lightClient != nil {
+			lightClient, err = dialLightClient(ctx, *o.lightClient, o.appDesc.Chain.Id, o.tendermintEndpoints)
+			if err != nil {
+				return nil, err
+			}
+			cleanup = append(cleanup, func() { _ = lightClient.Close() })
+			watcherOpts = append(watcherOpts, tx.WithInclusionVerifier(txInclusionVerifier(lightClient, tm)))
+		}
+
+		txWatcher, err = tx.DialWatcher(ctx, started, watcherOpts...)
+		if err != nil {
+			return nil, err
+		}
+		cleanup = append(cleanup, func() { _ = txWatcher.Stop() })
 	}
 
-	return &Client{
-		App:         o.appDesc,
-		Codec:       cdc,
-		Addresses:   addresses,
-		dynQueriers: nil,
-		dynMessage:  nil,
-		tm:          tm,
-		grpc:        conn,
-		watcher:     txWatcher,
-		txSvc:       txv1beta1.NewServiceClient(conn),
-		authOpt:     o.auth,
-	}, nil
+	client := &Client{
+		App:       o.appDesc,
+		Codec:     cdc,
+		Addresses: addresses,
+		tm:        tm,
+		grpc:      conn,
+		watcher:   txWatcher,
+		txSvc:     txv1beta1.NewServiceClient(conn),
+		authOpt:   o.auth,
+		Chain:     o.chain,
+		gasPrice:  o.gasPrice,
+		appHashes: o.appHashes,
+		light:     lightClient,
+	}
+	if client.appHashes == nil && lightClient != nil {
+		client.appHashes = lightClient
+	}
+	if !o.lazyCatalog {
+		_, err = client.Catalog()
+		if err != nil {
+			return nil, fmt.Errorf("unable to build catalog: %w", err)
+		}
+	}
+	return client, nil
 }
 
-func (o *options) setAppDesc(ctx context.Context, conn grpc.ClientConnInterface) error {
+func (o *options) setAppDesc(ctx context.Context, conn grpc.ClientConnInterface, registry *codec.Registry) error {
 	rc := reflectionv2alpha1.NewReflectionServiceClient(conn)
 	authn, err := rc.GetAuthnDescriptor(ctx, &reflectionv2alpha1.GetAuthnDescriptorRequest{})
+	// older chains do not expose v2alpha1 reflection
+	if isUnimplemented(err) {
+		return o.fallbackAppDesc(ctx, conn, registry)
+	}
 	if err != nil {
 		return err
 	}
@@ -155,7 +256,11 @@ func (o *options) setAppDesc(ctx context.Context, conn grpc.ClientConnInterface)
 }
 
 func (o *options) addresses(configuration *reflectionv2alpha1.ConfigurationDescriptor) *Addresses {
-	return NewAddresses(configuration.Bech32AccountAddressPrefix)
+	prefix := configuration.GetBech32AccountAddressPrefix()
+	if prefix == "" && o.chain != nil {
+		prefix = o.chain.Bech32Prefix
+	}
+	return NewAddresses(prefix)
 }
 
 // DialOption defines a Client Dial option
@@ -176,6 +281,13 @@ func WithAppDescriptor(desc *reflectionv2alpha1.AppDescriptor) DialOption {
 	}
 }
 
+// WithCodecOptions sets the encoding options of the Client Codec.
+func WithCodecOptions(opts ...codec.Option) DialOption {
+	return func(options *options) {
+		options.codecOpts = append(options.codecOpts, opts...)
+	}
+}
+
 type authenticationOptions struct {
 	signer             Signer
 	signerInfoProvider SignerInfoProvider
@@ -237,6 +349,22 @@ func (e erroringSigner) PubKeyForAddr(_ string) (*anypb.Any, error) {
 	return nil, fmt.Errorf("this setup does not support sending transactions")
 }
 
+var _ grpc.ClientConnInterface = codecConn{}
+
+// codecConn is a grpc.ClientConnInterface forcing the provided codec on every call
+type codecConn struct {
+	grpc.ClientConnInterface
+	codec encoding.Codec
+}
+
+func (c codecConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	return c.ClientConnInterface.Invoke(ctx, method, args, reply, append(opts, grpc.ForceCodec(c.codec))...)
+}
+
+func (c codecConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return c.ClientConnInterface.NewStream(ctx, desc, method, append(opts, grpc.ForceCodec(c.codec))...)
+}
+
 var _ grpc.ClientConnInterface = (*erroringConn)(nil)
 
 // erroringConn is a grpc.ClientConnInterface that returns the provided error
@@ -0,0 +1,281 @@
+package dynamic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	pageRequestName  protoreflect.FullName = "cosmos.base.query.v1beta1.PageRequest"
+	pageResponseName protoreflect.FullName = "cosmos.base.query.v1beta1.PageResponse"
+)
+
+// PaginateOption configures a Paginator.
+type PaginateOption func(*paginateOptions)
+
+type paginateOptions struct {
+	limit      uint64
+	prefetch   int
+	maxItems   int
+	itemsField protoreflect.Name
+	queryOpts  []QueryOption
+}
+
+// WithPageLimit sets the number of items requested for each page,
+// overriding the limit of the request pagination.
+func WithPageLimit(limit uint64) PaginateOption {
+	return func(o *paginateOptions) {
+		o.limit = limit
+	}
+}
+
+// WithPagePrefetch sets how many pages are fetched ahead of the ones being iterated,
+// while the current one is consumed. Pages are always requested sequentially, as each
+// request depends on the next key of the previous page. Defaults to 1.
+func WithPagePrefetch(pages int) PaginateOption {
+	return func(o *paginateOptions) {
+		o.prefetch = pages
+	}
+}
+
+// WithMaxItems stops the iteration after the given number of items.
+func WithMaxItems(n int) PaginateOption {
+	return func(o *paginateOptions) {
+		o.maxItems = n
+	}
+}
+
+// WithItemsField sets the repeated response field containing the items, which is
+// required only if the response has more than one repeated field.
+func WithItemsField(name protoreflect.Name) PaginateOption {
+	return func(o *paginateOptions) {
+		o.itemsField = name
+	}
+}
+
+// WithQueryOptions sets the options used to query every page, ex: AtHeight.
+func WithQueryOptions(opts ...QueryOption) PaginateOption {
+	return func(o *paginateOptions) {
+		o.queryOpts = append(o.queryOpts, opts...)
+	}
+}
+
+// Paginator iterates over the items of all the pages of a paginated query.
+// It must be closed when the iteration is terminated early.
+type Paginator struct {
+	cancel   context.CancelFunc
+	pages    chan page
+	maxItems int
+	itemsFd  protoreflect.FieldDescriptor
+
+	items protoreflect.List
+	index int
+	count int
+	item  protoreflect.Value
+	resp  protoreflect.Message
+	err   error
+}
+
+type page struct {
+	resp protoreflect.Message
+	err  error
+}
+
+// Paginate iterates over the pages of the query method, see QueryJSON for the accepted
+// path formats, which must have a cosmos.base.query.v1beta1.PageRequest field in its
+// request and a cosmos.base.query.v1beta1.PageResponse field in its response. Pages are
+// requested using the next key of the previous page until it is empty. req can be nil,
+// in which case an empty request is used, it is not modified.
+func (c *Client) Paginate(ctx context.Context, method string, req proto.Message, opts ...PaginateOption) (*Paginator, error) {
+	o := &paginateOptions{prefetch: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.prefetch < 1 {
+		return nil, fmt.Errorf("invalid page prefetch %d", o.prefetch)
+	}
+
+	md, err := c.findMethod(method)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil {
+		req = dynamicpb.NewMessage(md.Input())
+	}
+	if req.ProtoReflect().Descriptor().FullName() != md.Input().FullName() {
+		return nil, fmt.Errorf("invalid request %s for method %s", req.ProtoReflect().Descriptor().FullName(), md.FullName())
+	}
+
+	// the request descriptor is used, as it might not be the registry one
+	pageReq := fieldOfType(req.ProtoReflect().Descriptor(), pageRequestName)
+	if pageReq == nil {
+		return nil, fmt.Errorf("request %s is not paginated", md.Input().FullName())
+	}
+	pageResp := fieldOfType(md.Output(), pageResponseName)
+	if pageResp == nil {
+		return nil, fmt.Errorf("response %s is not paginated", md.Output().FullName())
+	}
+	items, err := itemsField(md.Output(), o.itemsField)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p := &Paginator{
+		cancel:   cancel,
+		pages:    make(chan page, o.prefetch-1),
+		maxItems: o.maxItems,
+		itemsFd:  items,
+	}
+
+	path := fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+	req = proto.Clone(req)
+	setPageRequest(req.ProtoReflect(), pageReq, nil, o.limit, true)
+
+	go func() {
+		defer close(p.pages)
+		var prevKey []byte
+		for {
+			resp := dynamicpb.NewMessage(md.Output())
+			err := c.DynamicQuery(ctx, path, req, resp, o.queryOpts...)
+			select {
+			case p.pages <- page{resp: resp, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+
+			nextKey := resp.Get(pageResp).Message().Get(pageResp.Message().Fields().ByName("next_key")).Bytes()
+			if len(nextKey) == 0 {
+				return
+			}
+			// a node returning the same key again would make the iteration endless
+			if bytes.Equal(nextKey, prevKey) {
+				select {
+				case p.pages <- page{err: fmt.Errorf("the node returned the same next_key %X twice", nextKey)}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			prevKey = nextKey
+			req = proto.Clone(req)
+			setPageRequest(req.ProtoReflect(), pageReq, nextKey, o.limit, false)
+		}
+	}()
+
+	return p, nil
+}
+
+// Next advances to the next item, returning false when there are no more
+// items, the max items are reached or an error occurred, see Err.
+func (p *Paginator) Next() bool {
+	if p.err != nil || (p.maxItems > 0 && p.count >= p.maxItems) {
+		p.Close()
+		return false
+	}
+	for p.items == nil || p.index >= p.items.Len() {
+		pg, ok := <-p.pages
+		if !ok {
+			p.cancel()
+			return false
+		}
+		if pg.err != nil {
+			p.err = pg.err
+			p.Close()
+			return false
+		}
+		p.resp = pg.resp
+		p.items = pg.resp.Get(p.itemsFd).List()
+		p.index = 0
+	}
+
+	p.item = p.items.Get(p.index)
+	p.index++
+	p.count++
+	return true
+}
+
+// Item returns the current item.
+func (p *Paginator) Item() protoreflect.Value {
+	return p.item
+}
+
+// Page returns the response of the page containing the current item.
+func (p *Paginator) Page() protoreflect.Message {
+	return p.resp
+}
+
+// Err returns the error which terminated the iteration, if any.
+func (p *Paginator) Err() error {
+	return p.err
+}
+
+// Close terminates the iteration, cancelling the pending page requests.
+// It is safe to call it multiple times.
+func (p *Paginator) Close() {
+	p.cancel()
+	// wait for the page fetcher to exit
+	for range p.pages {
+	}
+}
+
+// setPageRequest sets the key and limit of the page request field of req. The offset,
+// which cannot be used together with the key, and count total are reset after the first page.
+func setPageRequest(req protoreflect.Message, fd protoreflect.FieldDescriptor, key []byte, limit uint64, first bool) {
+	pageReq := req.Mutable(fd).Message()
+	fields := fd.Message().Fields()
+	if limit != 0 {
+		pageReq.Set(fields.ByName("limit"), protoreflect.ValueOfUint64(limit))
+	}
+	if first {
+		return
+	}
+	pageReq.Set(fields.ByName("key"), protoreflect.ValueOfBytes(key))
+	pageReq.Clear(fields.ByName("offset"))
+	pageReq.Clear(fields.ByName("count_total"))
+}
+
+// fieldOfType returns the singular field of md of the given message type.
+func fieldOfType(md protoreflect.MessageDescriptor, name protoreflect.FullName) protoreflect.FieldDescriptor {
+	for i := 0; i < md.Fields().Len(); i++ {
+		fd := md.Fields().Get(i)
+		if fd.Message() != nil && fd.Message().FullName() == name && fd.Cardinality() != protoreflect.Repeated {
+			return fd
+		}
+	}
+	return nil
+}
+
+// itemsField returns the repeated field of md named name, or the only one if name is empty.
+func itemsField(md protoreflect.MessageDescriptor, name protoreflect.Name) (protoreflect.FieldDescriptor, error) {
+	if name != "" {
+		fd := md.Fields().ByName(name)
+		if fd == nil || !fd.IsList() {
+			return nil, fmt.Errorf("response %s has no repeated field %s", md.FullName(), name)
+		}
+		return fd, nil
+	}
+
+	var found protoreflect.FieldDescriptor
+	for i := 0; i < md.Fields().Len(); i++ {
+		fd := md.Fields().Get(i)
+		if !fd.IsList() {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("response %s has multiple repeated fields, the items one must be set using WithItemsField", md.FullName())
+		}
+		found = fd
+	}
+	if found == nil {
+		return nil, fmt.Errorf("response %s has no repeated field", md.FullName())
+	}
+	return found, nil
+}
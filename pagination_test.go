@@ -0,0 +1,113 @@
+package dynamic
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	bankv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/bank/v1beta1"
+	queryv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/query/v1beta1"
+	basev1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/v1beta1"
+	"github.com/fdymylja/dynamic-cosmos/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// pagedBank serves balances paginated using the index of the next balance as key.
+type pagedBank struct {
+	bankv1beta1.UnimplementedQueryServer
+	balances []*basev1beta1.Coin
+
+	// mu guards the fields below, as cancelled queries can overlap with the next ones.
+	mu       sync.Mutex
+	requests int
+	// stuck makes the node always return the first next key.
+	stuck bool
+}
+
+func (b *pagedBank) setStuck(stuck bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stuck = stuck
+}
+
+func (b *pagedBank) requestCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.requests
+}
+
+func (b *pagedBank) AllBalances(_ context.Context, req *bankv1beta1.QueryAllBalancesRequest) (*bankv1beta1.QueryAllBalancesResponse, error) {
+	b.mu.Lock()
+	b.requests++
+	stuck := b.stuck
+	b.mu.Unlock()
+
+	start := 0
+	if key := req.Pagination.GetKey(); key != nil {
+		start, _ = strconv.Atoi(string(key))
+	}
+	end := start + int(req.Pagination.GetLimit())
+	resp := &bankv1beta1.QueryAllBalancesResponse{Pagination: &queryv1beta1.PageResponse{}}
+	if end >= len(b.balances) {
+		end = len(b.balances)
+	} else {
+		resp.Pagination.NextKey = []byte(strconv.Itoa(end))
+	}
+	if stuck {
+		resp.Pagination.NextKey = []byte(strconv.Itoa(int(req.Pagination.GetLimit())))
+	}
+	resp.Balances = b.balances[start:end]
+	return resp, nil
+}
+
+func TestClient_Paginate(t *testing.T) {
+	bank := &pagedBank{}
+	for i := 0; i < 5; i++ {
+		bank.balances = append(bank.balances, &basev1beta1.Coin{Denom: "denom" + strconv.Itoa(i), Amount: "1"})
+	}
+	conn := testutil.NewConn(t, func(srv *grpc.Server) {
+		bankv1beta1.RegisterQueryServer(srv, bank)
+	})
+
+	c, err := DialConn(context.Background(), conn, "", WithAppDescriptor(testAppDescriptor()))
+	require.NoError(t, err)
+	defer c.Close()
+
+	const method = "cosmos.bank.v1beta1.Query/AllBalances"
+	p, err := c.Paginate(context.Background(), method, &bankv1beta1.QueryAllBalancesRequest{Address: "cosmos1"}, WithPageLimit(2))
+	require.NoError(t, err)
+	var denoms []string
+	for p.Next() {
+		denoms = append(denoms, p.Item().Message().Get(p.Item().Message().Descriptor().Fields().ByName("denom")).String())
+	}
+	require.NoError(t, p.Err())
+	require.Equal(t, []string{"denom0", "denom1", "denom2", "denom3", "denom4"}, denoms)
+	require.Equal(t, 3, bank.requestCount())
+
+	// early termination
+	p, err = c.Paginate(context.Background(), method, nil, WithPageLimit(1), WithMaxItems(2))
+	require.NoError(t, err)
+	n := 0
+	for p.Next() {
+		n++
+	}
+	p.Close()
+	require.NoError(t, p.Err())
+	require.Equal(t, 2, n)
+
+	// a node repeating the next key fails the iteration instead of looping forever
+	bank.setStuck(true)
+	p, err = c.Paginate(context.Background(), method, nil, WithPageLimit(2))
+	require.NoError(t, err)
+	n = 0
+	for p.Next() {
+		n++
+	}
+	require.ErrorContains(t, p.Err(), "same next_key")
+	require.Equal(t, 4, n)
+
+	_, err = c.Paginate(context.Background(), "grpc.health.v1.Health/Check", nil)
+	require.ErrorContains(t, err, "is not paginated")
+}
@@ -0,0 +1,79 @@
+// Package proof verifies the ICS-23 merkle proofs returned by cosmos-sdk nodes
+// for ABCI store queries against the app hash of the chain.
+package proof
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	ics23 "github.com/confio/ics23/go"
+	tmcrypto "github.com/tendermint/tendermint/proto/tendermint/crypto"
+)
+
+const (
+	// OpIAVL is the type of the proof op proving a key in an IAVL module store.
+	OpIAVL = "ics23:iavl"
+	// OpSimple is the type of the proof op proving a module store root in the app hash.
+	OpSimple = "ics23:simple"
+)
+
+// ErrInvalidProof is matched by the errors returned when a proof does not verify.
+var ErrInvalidProof = errors.New("invalid proof")
+
+// VerifyStoreValue verifies that ops prove that key has value in the module store
+// named store, committed by appHash. A nil value verifies that the key is absent.
+// The first op proves the key in the IAVL store, the second proves the store root
+// in the multistore, as returned by abci_query using the /store/<store>/key path.
+func VerifyStoreValue(ops *tmcrypto.ProofOps, appHash []byte, store string, key, value []byte) error {
+	if len(ops.GetOps()) != 2 {
+		return fmt.Errorf("%w: expected 2 proof ops, got %d", ErrInvalidProof, len(ops.GetOps()))
+	}
+	storeOp, rootOp := ops.Ops[0], ops.Ops[1]
+	if storeOp.Type != OpIAVL || rootOp.Type != OpSimple {
+		return fmt.Errorf("%w: unexpected proof ops %s and %s", ErrInvalidProof, storeOp.Type, rootOp.Type)
+	}
+	if !bytes.Equal(storeOp.Key, key) {
+		return fmt.Errorf("%w: proof is for key %X, expected %X", ErrInvalidProof, storeOp.Key, key)
+	}
+	if string(rootOp.Key) != store {
+		return fmt.Errorf("%w: proof is for store %s, expected %s", ErrInvalidProof, rootOp.Key, store)
+	}
+
+	storeProof, err := commitmentProof(storeOp)
+	if err != nil {
+		return err
+	}
+	rootProof, err := commitmentProof(rootOp)
+	if err != nil {
+		return err
+	}
+
+	// the store root is not known, so it's calculated from the proof
+	// and then proven to be committed by the app hash
+	storeRoot, err := storeProof.Calculate()
+	if err != nil {
+		return fmt.Errorf("%w: unable to calculate store root: %s", ErrInvalidProof, err)
+	}
+	if value == nil {
+		if !ics23.VerifyNonMembership(ics23.IavlSpec, storeRoot, storeProof, key) {
+			return fmt.Errorf("%w: key %X absence is not proven in store %s", ErrInvalidProof, key, store)
+		}
+	} else if !ics23.VerifyMembership(ics23.IavlSpec, storeRoot, storeProof, key, value) {
+		return fmt.Errorf("%w: key %X value is not proven in store %s", ErrInvalidProof, key, store)
+	}
+
+	if !ics23.VerifyMembership(ics23.TendermintSpec, appHash, rootProof, []byte(store), storeRoot) {
+		return fmt.Errorf("%w: store %s root is not committed by app hash %X", ErrInvalidProof, store, appHash)
+	}
+	return nil
+}
+
+func commitmentProof(op tmcrypto.ProofOp) (*ics23.CommitmentProof, error) {
+	proof := new(ics23.CommitmentProof)
+	err := proof.Unmarshal(op.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to decode %s proof: %s", ErrInvalidProof, op.Type, err)
+	}
+	return proof, nil
+}
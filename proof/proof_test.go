@@ -0,0 +1,85 @@
+package proof
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	ics23 "github.com/confio/ics23/go"
+	"github.com/stretchr/testify/require"
+	tmcrypto "github.com/tendermint/tendermint/proto/tendermint/crypto"
+)
+
+// tree is a two leaves tree, with inner nodes built as IAVL or tendermint simple merkle ones.
+type tree struct {
+	left, right *ics23.ExistenceProof
+	root        []byte
+}
+
+func newTree(t *testing.T, spec *ics23.ProofSpec, innerPrefix []byte, leftKey, leftValue, rightKey, rightValue []byte) *tree {
+	leftHash, err := spec.LeafSpec.Apply(leftKey, leftValue)
+	require.NoError(t, err)
+	rightHash, err := spec.LeafSpec.Apply(rightKey, rightValue)
+	require.NoError(t, err)
+
+	// the IAVL inner nodes prefix the children hashes with their length
+	lengthPrefix := []byte(nil)
+	if spec.InnerSpec.ChildSize == 33 {
+		lengthPrefix = []byte{32}
+	}
+	leftOp := &ics23.InnerOp{
+		Hash:   ics23.HashOp_SHA256,
+		Prefix: append(append([]byte{}, innerPrefix...), lengthPrefix...),
+		Suffix: append(append([]byte{}, lengthPrefix...), rightHash...),
+	}
+	rightOp := &ics23.InnerOp{
+		Hash:   ics23.HashOp_SHA256,
+		Prefix: append(append(append(append([]byte{}, innerPrefix...), lengthPrefix...), leftHash...), lengthPrefix...),
+	}
+	root, err := leftOp.Apply(leftHash)
+	require.NoError(t, err)
+
+	return &tree{
+		left:  &ics23.ExistenceProof{Key: leftKey, Value: leftValue, Leaf: spec.LeafSpec, Path: []*ics23.InnerOp{leftOp}},
+		right: &ics23.ExistenceProof{Key: rightKey, Value: rightValue, Leaf: spec.LeafSpec, Path: []*ics23.InnerOp{rightOp}},
+		root:  root,
+	}
+}
+
+func proofOp(t *testing.T, typ string, key []byte, proof *ics23.CommitmentProof) tmcrypto.ProofOp {
+	b, err := proof.Marshal()
+	require.NoError(t, err)
+	return tmcrypto.ProofOp{Type: typ, Key: key, Data: b}
+}
+
+func TestVerifyStoreValue(t *testing.T) {
+	// height 1, size 2, version 1 as zigzag varints
+	bank := newTree(t, ics23.IavlSpec, []byte{2, 4, 2}, []byte("a"), []byte("1"), []byte("c"), []byte("3"))
+	multi := newTree(t, ics23.TendermintSpec, []byte{1}, []byte("acc"), sha256.New().Sum(nil), []byte("bank"), bank.root)
+
+	rootOp := proofOp(t, OpSimple, []byte("bank"), &ics23.CommitmentProof{Proof: &ics23.CommitmentProof_Exist{Exist: multi.right}})
+	exists := &tmcrypto.ProofOps{Ops: []tmcrypto.ProofOp{
+		proofOp(t, OpIAVL, []byte("c"), &ics23.CommitmentProof{Proof: &ics23.CommitmentProof_Exist{Exist: bank.right}}),
+		rootOp,
+	}}
+	require.NoError(t, VerifyStoreValue(exists, multi.root, "bank", []byte("c"), []byte("3")))
+
+	// tampered value, app hash, store and key
+	require.ErrorIs(t, VerifyStoreValue(exists, multi.root, "bank", []byte("c"), []byte("4")), ErrInvalidProof)
+	require.ErrorIs(t, VerifyStoreValue(exists, bank.root, "bank", []byte("c"), []byte("3")), ErrInvalidProof)
+	require.ErrorIs(t, VerifyStoreValue(exists, multi.root, "acc", []byte("c"), []byte("3")), ErrInvalidProof)
+	require.ErrorIs(t, VerifyStoreValue(exists, multi.root, "bank", []byte("a"), []byte("1")), ErrInvalidProof)
+
+	absent := &tmcrypto.ProofOps{Ops: []tmcrypto.ProofOp{
+		proofOp(t, OpIAVL, []byte("b"), &ics23.CommitmentProof{Proof: &ics23.CommitmentProof_Nonexist{Nonexist: &ics23.NonExistenceProof{
+			Key:   []byte("b"),
+			Left:  bank.left,
+			Right: bank.right,
+		}}}),
+		rootOp,
+	}}
+	require.NoError(t, VerifyStoreValue(absent, multi.root, "bank", []byte("b"), nil))
+	// an absence proof does not prove a value
+	require.ErrorIs(t, VerifyStoreValue(absent, multi.root, "bank", []byte("b"), []byte("2")), ErrInvalidProof)
+
+	require.ErrorIs(t, VerifyStoreValue(nil, multi.root, "bank", []byte("b"), nil), ErrInvalidProof)
+}
@@ -0,0 +1,681 @@
+// Package protoprint renders protobuf file descriptors, such as the ones
+// fetched from a chain by codec.Registry, back to .proto source files.
+package protoprint
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// WriteSet renders every file contained in the FileDescriptorSet, as
+// returned by codec.Registry.Save, into the dir directory.
+func WriteSet(dir string, set *descriptorpb.FileDescriptorSet) error {
+	files, err := protodesc.FileOptions{AllowUnresolvable: true}.NewFiles(set)
+	if err != nil {
+		return err
+	}
+
+	return WriteFiles(dir, files)
+}
+
+// WriteFiles renders every file into the dir directory, each file is
+// written to its path relative to dir, preserving the package layout.
+func WriteFiles(dir string, files *protoregistry.Files) error {
+	var err error
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		err = writeFile(dir, fd)
+		return err == nil
+	})
+
+	return err
+}
+
+func writeFile(dir string, fd protoreflect.FileDescriptor) error {
+	// file paths come from remote nodes, so we
+	// don't allow them to escape the target dir.
+	path := filepath.Clean(filepath.FromSlash(fd.Path()))
+	if filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("invalid file path: %s", fd.Path())
+	}
+	path = filepath.Join(dir, path)
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = Print(f, fd)
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("unable to render %s: %w", fd.Path(), err)
+	}
+
+	return f.Close()
+}
+
+// Print renders the file descriptor as .proto source. Custom options are
+// resolved using the extensions defined in the file and in its imports.
+func Print(w io.Writer, fd protoreflect.FileDescriptor) error {
+	p := &printer{
+		file:       fd,
+		extensions: new(protoregistry.Types),
+	}
+	p.registerExtensions(fd, map[string]struct{}{})
+
+	p.printFile()
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+type printer struct {
+	buf    bytes.Buffer
+	indent int
+
+	file       protoreflect.FileDescriptor
+	extensions *protoregistry.Types
+}
+
+func (p *printer) registerExtensions(fd protoreflect.FileDescriptor, seen map[string]struct{}) {
+	if _, ok := seen[fd.Path()]; ok {
+		return
+	}
+	seen[fd.Path()] = struct{}{}
+
+	p.registerExtensionDescriptors(fd.Extensions())
+	p.registerNestedExtensions(fd.Messages())
+	for i := 0; i < fd.Imports().Len(); i++ {
+		p.registerExtensions(fd.Imports().Get(i).FileDescriptor, seen)
+	}
+}
+
+func (p *printer) registerNestedExtensions(mds protoreflect.MessageDescriptors) {
+	for i := 0; i < mds.Len(); i++ {
+		p.registerExtensionDescriptors(mds.Get(i).Extensions())
+		p.registerNestedExtensions(mds.Get(i).Messages())
+	}
+}
+
+func (p *printer) registerExtensionDescriptors(xds protoreflect.ExtensionDescriptors) {
+	for i := 0; i < xds.Len(); i++ {
+		// conflicts are ignored, the first definition wins
+		_ = p.extensions.RegisterExtension(dynamicpb.NewExtensionType(xds.Get(i)))
+	}
+}
+
+// FindExtensionByName implements protoregistry.ExtensionTypeResolver,
+// falling back to the global registry for extensions the files don't define.
+func (p *printer) FindExtensionByName(name protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	xt, err := p.extensions.FindExtensionByName(name)
+	if err == nil {
+		return xt, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByName(name)
+}
+
+// FindExtensionByNumber implements protoregistry.ExtensionTypeResolver,
+// falling back to the global registry for extensions the files don't define.
+func (p *printer) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	xt, err := p.extensions.FindExtensionByNumber(message, field)
+	if err == nil {
+		return xt, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
+
+func (p *printer) FindMessageByName(message protoreflect.FullName) (protoreflect.MessageType, error) {
+	return protoregistry.GlobalTypes.FindMessageByName(message)
+}
+
+func (p *printer) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	return protoregistry.GlobalTypes.FindMessageByURL(url)
+}
+
+func (p *printer) printf(format string, args ...interface{}) {
+	p.buf.WriteString(strings.Repeat("  ", p.indent))
+	fmt.Fprintf(&p.buf, format, args...)
+}
+
+func (p *printer) newline() {
+	p.buf.WriteByte('\n')
+}
+
+func (p *printer) printFile() {
+	fd := p.file
+
+	p.leadingComments(fd)
+	switch fd.Syntax() {
+	case protoreflect.Proto3:
+		p.printf("syntax = \"proto3\";\n")
+	default:
+		p.printf("syntax = \"proto2\";\n")
+	}
+
+	if fd.Package() != "" {
+		p.newline()
+		p.printf("package %s;\n", fd.Package())
+	}
+
+	if fd.Imports().Len() != 0 {
+		p.newline()
+	}
+	for i := 0; i < fd.Imports().Len(); i++ {
+		imp := fd.Imports().Get(i)
+		switch {
+		case imp.IsPublic:
+			p.printf("import public %s;\n", quote(imp.Path(), false))
+		case imp.IsWeak:
+			p.printf("import weak %s;\n", quote(imp.Path(), false))
+		default:
+			p.printf("import %s;\n", quote(imp.Path(), false))
+		}
+	}
+
+	if opts := p.options(fd.Options()); len(opts) != 0 {
+		p.newline()
+		for _, opt := range opts {
+			p.printf("option %s = %s;\n", opt.name, opt.value)
+		}
+	}
+
+	for i := 0; i < fd.Services().Len(); i++ {
+		p.newline()
+		p.printService(fd.Services().Get(i))
+	}
+	p.printExtensions(fd.Extensions())
+	for i := 0; i < fd.Messages().Len(); i++ {
+		p.newline()
+		p.printMessage(fd.Messages().Get(i))
+	}
+	for i := 0; i < fd.Enums().Len(); i++ {
+		p.newline()
+		p.printEnum(fd.Enums().Get(i))
+	}
+}
+
+func (p *printer) printService(sd protoreflect.ServiceDescriptor) {
+	p.leadingComments(sd)
+	p.printf("service %s {", sd.Name())
+	p.trailingComments(sd)
+	p.indent++
+	p.printOptionStatements(sd.Options())
+	for i := 0; i < sd.Methods().Len(); i++ {
+		md := sd.Methods().Get(i)
+		p.leadingComments(md)
+
+		input, output := typeName(md.Input()), typeName(md.Output())
+		if md.IsStreamingClient() {
+			input = "stream " + input
+		}
+		if md.IsStreamingServer() {
+			output = "stream " + output
+		}
+
+		opts := p.options(md.Options())
+		if len(opts) == 0 {
+			p.printf("rpc %s(%s) returns (%s);", md.Name(), input, output)
+			p.trailingComments(md)
+			continue
+		}
+
+		p.printf("rpc %s(%s) returns (%s) {", md.Name(), input, output)
+		p.trailingComments(md)
+		p.indent++
+		for _, opt := range opts {
+			p.printf("option %s = %s;\n", opt.name, opt.value)
+		}
+		p.indent--
+		p.printf("}\n")
+	}
+	p.indent--
+	p.printf("}\n")
+}
+
+func (p *printer) printMessage(md protoreflect.MessageDescriptor) {
+	p.leadingComments(md)
+	p.printf("message %s {", md.Name())
+	p.trailingComments(md)
+	p.indent++
+	p.printMessageBody(md)
+	p.indent--
+	p.printf("}\n")
+}
+
+func (p *printer) printMessageBody(md protoreflect.MessageDescriptor) {
+	p.printOptionStatements(md.Options())
+
+	// fields belonging to the same oneof are printed
+	// together, at the position of the first one.
+	printedOneofs := map[protoreflect.FullName]struct{}{}
+	for i := 0; i < md.Fields().Len(); i++ {
+		fd := md.Fields().Get(i)
+		od := fd.ContainingOneof()
+		if od == nil || od.IsSynthetic() {
+			p.printField(fd)
+			continue
+		}
+		if _, printed := printedOneofs[od.FullName()]; printed {
+			continue
+		}
+		printedOneofs[od.FullName()] = struct{}{}
+		p.printOneof(od)
+	}
+
+	for i := 0; i < md.Messages().Len(); i++ {
+		nested := md.Messages().Get(i)
+		// map entries and groups are printed as part of their field
+		if nested.IsMapEntry() || isGroupMessage(nested) {
+			continue
+		}
+		p.newline()
+		p.printMessage(nested)
+	}
+	for i := 0; i < md.Enums().Len(); i++ {
+		p.newline()
+		p.printEnum(md.Enums().Get(i))
+	}
+	p.printExtensions(md.Extensions())
+
+	if ranges := md.ExtensionRanges(); ranges.Len() != 0 {
+		p.printf("extensions %s;\n", formatRanges(ranges.Len(), ranges.Get, true))
+	}
+	p.printReserved(md.ReservedRanges().Len(), md.ReservedRanges().Get, true, md.ReservedNames())
+}
+
+func (p *printer) printOneof(od protoreflect.OneofDescriptor) {
+	p.leadingComments(od)
+	p.printf("oneof %s {", od.Name())
+	p.trailingComments(od)
+	p.indent++
+	p.printOptionStatements(od.Options())
+	for i := 0; i < od.Fields().Len(); i++ {
+		p.printField(od.Fields().Get(i))
+	}
+	p.indent--
+	p.printf("}\n")
+}
+
+func (p *printer) printField(fd protoreflect.FieldDescriptor) {
+	p.leadingComments(fd)
+
+	var opts []option
+	if fd.HasDefault() {
+		opts = append(opts, option{name: "default", value: defaultValue(fd)})
+	}
+	if fd.HasJSONName() && fd.JSONName() != jsonCamelCase(string(fd.Name())) {
+		opts = append(opts, option{name: "json_name", value: quote(fd.JSONName(), false)})
+	}
+	opts = append(opts, p.options(fd.Options())...)
+
+	var inlineOpts string
+	if len(opts) != 0 {
+		formatted := make([]string, len(opts))
+		for i, opt := range opts {
+			formatted[i] = opt.name + " = " + opt.value
+		}
+		inlineOpts = " [" + strings.Join(formatted, ", ") + "]"
+	}
+
+	if fd.Kind() == protoreflect.GroupKind && isGroupMessage(fd.Message()) {
+		p.printf("%sgroup %s = %d%s {", fieldLabel(fd), fd.Message().Name(), fd.Number(), inlineOpts)
+		p.trailingComments(fd)
+		p.indent++
+		p.printMessageBody(fd.Message())
+		p.indent--
+		p.printf("}\n")
+		return
+	}
+
+	p.printf("%s%s %s = %d%s;", fieldLabel(fd), fieldType(fd), fd.Name(), fd.Number(), inlineOpts)
+	p.trailingComments(fd)
+}
+
+// printExtensions prints extension declarations grouped by the message they extend.
+func (p *printer) printExtensions(xds protoreflect.ExtensionDescriptors) {
+	var extendees []protoreflect.FullName
+	byExtendee := map[protoreflect.FullName][]protoreflect.ExtensionDescriptor{}
+	for i := 0; i < xds.Len(); i++ {
+		xd := xds.Get(i)
+		extendee := xd.ContainingMessage().FullName()
+		if _, exists := byExtendee[extendee]; !exists {
+			extendees = append(extendees, extendee)
+		}
+		byExtendee[extendee] = append(byExtendee[extendee], xd)
+	}
+
+	for _, extendee := range extendees {
+		p.newline()
+		p.printf("extend .%s {\n", extendee)
+		p.indent++
+		for _, xd := range byExtendee[extendee] {
+			p.printField(xd)
+		}
+		p.indent--
+		p.printf("}\n")
+	}
+}
+
+func (p *printer) printEnum(ed protoreflect.EnumDescriptor) {
+	p.leadingComments(ed)
+	p.printf("enum %s {", ed.Name())
+	p.trailingComments(ed)
+	p.indent++
+	p.printOptionStatements(ed.Options())
+	for i := 0; i < ed.Values().Len(); i++ {
+		vd := ed.Values().Get(i)
+		p.leadingComments(vd)
+
+		var inlineOpts string
+		if opts := p.options(vd.Options()); len(opts) != 0 {
+			formatted := make([]string, len(opts))
+			for i, opt := range opts {
+				formatted[i] = opt.name + " = " + opt.value
+			}
+			inlineOpts = " [" + strings.Join(formatted, ", ") + "]"
+		}
+
+		p.printf("%s = %d%s;", vd.Name(), vd.Number(), inlineOpts)
+		p.trailingComments(vd)
+	}
+	p.printReserved(ed.ReservedRanges().Len(), func(i int) [2]protoreflect.FieldNumber {
+		r := ed.ReservedRanges().Get(i)
+		// enum ranges are inclusive, we make them exclusive like field ones
+		return [2]protoreflect.FieldNumber{protoreflect.FieldNumber(r[0]), protoreflect.FieldNumber(r[1]) + 1}
+	}, false, ed.ReservedNames())
+	p.indent--
+	p.printf("}\n")
+}
+
+func (p *printer) printReserved(n int, get func(int) [2]protoreflect.FieldNumber, isField bool, names protoreflect.Names) {
+	if n != 0 {
+		p.printf("reserved %s;\n", formatRanges(n, get, isField))
+	}
+	if names.Len() != 0 {
+		quoted := make([]string, names.Len())
+		for i := 0; i < names.Len(); i++ {
+			quoted[i] = quote(string(names.Get(i)), false)
+		}
+		p.printf("reserved %s;\n", strings.Join(quoted, ", "))
+	}
+}
+
+func (p *printer) printOptionStatements(opts proto.Message) {
+	for _, opt := range p.options(opts) {
+		p.printf("option %s = %s;\n", opt.name, opt.value)
+	}
+}
+
+func (p *printer) leadingComments(desc protoreflect.Descriptor) {
+	loc := p.file.SourceLocations().ByDescriptor(desc)
+	for _, detached := range loc.LeadingDetachedComments {
+		p.comment(detached)
+		p.newline()
+	}
+	p.comment(loc.LeadingComments)
+}
+
+// trailingComments terminates the current line, appending
+// the trailing comments of desc if there are any.
+func (p *printer) trailingComments(desc protoreflect.Descriptor) {
+	loc := p.file.SourceLocations().ByDescriptor(desc)
+	comment := strings.TrimSuffix(loc.TrailingComments, "\n")
+	switch {
+	case comment == "":
+		p.newline()
+	case !strings.Contains(comment, "\n"):
+		p.buf.WriteString(" //" + comment)
+		p.newline()
+	default:
+		p.newline()
+		p.indent++
+		p.comment(loc.TrailingComments)
+		p.indent--
+	}
+}
+
+func (p *printer) comment(comment string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(comment, "\n"), "\n") {
+		p.printf("//%s\n", line)
+	}
+}
+
+type option struct {
+	name  string
+	value string
+}
+
+// options returns the populated options of an options message, sorted
+// by field number and followed by custom options sorted by name.
+func (p *printer) options(opts proto.Message) []option {
+	if opts == nil || !opts.ProtoReflect().IsValid() {
+		return nil
+	}
+
+	// custom options are parsed as unknown fields if their
+	// extension type was not known when the descriptor was
+	// decoded, so we decode them again using the file extensions.
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(opts)
+	if err != nil {
+		return nil
+	}
+	m := dynamicpb.NewMessage(opts.ProtoReflect().Descriptor())
+	err = proto.UnmarshalOptions{Resolver: p}.Unmarshal(b, m)
+	if err != nil {
+		return nil
+	}
+
+	var fields []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		fields = append(fields, fd)
+		return true
+	})
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].IsExtension() != fields[j].IsExtension() {
+			return !fields[i].IsExtension()
+		}
+		if fields[i].IsExtension() {
+			return fields[i].FullName() < fields[j].FullName()
+		}
+		return fields[i].Number() < fields[j].Number()
+	})
+
+	var result []option
+	for _, fd := range fields {
+		// map entry options are implied by the map syntax
+		if fd.FullName() == "google.protobuf.MessageOptions.map_entry" {
+			continue
+		}
+		// uninterpreted options are only produced by parsers
+		if fd.Name() == "uninterpreted_option" && !fd.IsExtension() {
+			continue
+		}
+
+		name := string(fd.Name())
+		if fd.IsExtension() {
+			name = "(" + string(fd.FullName()) + ")"
+		}
+
+		v := m.Get(fd)
+		if fd.IsList() {
+			for i := 0; i < v.List().Len(); i++ {
+				result = append(result, option{name: name, value: p.value(fd, v.List().Get(i))})
+			}
+			continue
+		}
+		result = append(result, option{name: name, value: p.value(fd, v)})
+	}
+
+	return result
+}
+
+func (p *printer) value(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if vd := fd.Enum().Values().ByNumber(v.Enum()); vd != nil {
+			return string(vd.Name())
+		}
+		return strconv.Itoa(int(v.Enum()))
+	case protoreflect.StringKind:
+		return quote(v.String(), false)
+	case protoreflect.BytesKind:
+		return quote(string(v.Bytes()), true)
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		b, err := prototext.MarshalOptions{Resolver: p}.Marshal(v.Message().Interface())
+		if err != nil {
+			return "{}"
+		}
+		return "{ " + strings.TrimSpace(string(b)) + " }"
+	default:
+		return v.String()
+	}
+}
+
+func fieldLabel(fd protoreflect.FieldDescriptor) string {
+	switch {
+	case fd.IsMap():
+		return ""
+	case fd.Cardinality() == protoreflect.Repeated:
+		return "repeated "
+	case fd.Cardinality() == protoreflect.Required:
+		return "required "
+	case fd.HasOptionalKeyword():
+		return "optional "
+	case fd.ContainingOneof() != nil:
+		return ""
+	case fd.Syntax() == protoreflect.Proto2 || fd.IsExtension() && fd.ParentFile().Syntax() == protoreflect.Proto2:
+		return "optional "
+	default:
+		return ""
+	}
+}
+
+func fieldType(fd protoreflect.FieldDescriptor) string {
+	switch {
+	case fd.IsMap():
+		return fmt.Sprintf("map<%s, %s>", fieldType(fd.MapKey()), fieldType(fd.MapValue()))
+	case fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind:
+		return typeName(fd.Message())
+	case fd.Kind() == protoreflect.EnumKind:
+		return typeName(fd.Enum())
+	default:
+		return fd.Kind().String()
+	}
+}
+
+// typeName returns the fully qualified name of a type reference,
+// so that it can't be shadowed by names in the referencing scope.
+func typeName(desc protoreflect.Descriptor) string {
+	return "." + string(desc.FullName())
+}
+
+// isGroupMessage reports if the message was declared using the proto2 group syntax.
+func isGroupMessage(md protoreflect.MessageDescriptor) bool {
+	parent, ok := md.Parent().(protoreflect.MessageDescriptor)
+	if !ok {
+		return false
+	}
+	fd := parent.Fields().ByName(protoreflect.Name(strings.ToLower(string(md.Name()))))
+	return fd != nil && fd.Kind() == protoreflect.GroupKind && fd.Message() == md
+}
+
+func defaultValue(fd protoreflect.FieldDescriptor) string {
+	v := fd.Default()
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		return string(fd.DefaultEnumValue().Name())
+	case protoreflect.StringKind:
+		return quote(v.String(), false)
+	case protoreflect.BytesKind:
+		return quote(string(v.Bytes()), true)
+	default:
+		return v.String()
+	}
+}
+
+const maxFieldNumber = protoreflect.FieldNumber(536870912) // exclusive
+
+func formatRanges(n int, get func(int) [2]protoreflect.FieldNumber, isField bool) string {
+	formatted := make([]string, n)
+	for i := 0; i < n; i++ {
+		r := get(i)
+		start, end := r[0], r[1]-1
+		switch {
+		case start == end:
+			formatted[i] = strconv.Itoa(int(start))
+		case isField && r[1] == maxFieldNumber, !isField && end == 1<<31-1:
+			formatted[i] = fmt.Sprintf("%d to max", start)
+		default:
+			formatted[i] = fmt.Sprintf("%d to %d", start, end)
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// quote quotes s as a protobuf string literal, when escapeAll is set
+// non ASCII bytes are escaped too, otherwise they're kept as UTF-8.
+func quote(s string, escapeAll bool) string {
+	b := new(strings.Builder)
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			b.WriteString(`\"`)
+		case c == '\\':
+			b.WriteString(`\\`)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c < 0x20 || c == 0x7f || (escapeAll && c >= 0x80):
+			fmt.Fprintf(b, `\%03o`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// jsonCamelCase returns the default JSON name of a field, as protoc computes it.
+func jsonCamelCase(s string) string {
+	b := new(strings.Builder)
+	upper := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_':
+			upper = true
+		case upper && 'a' <= c && c <= 'z':
+			b.WriteByte(c - 'a' + 'A')
+			upper = false
+		default:
+			b.WriteByte(c)
+			upper = false
+		}
+	}
+	return b.String()
+}
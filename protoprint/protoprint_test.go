@@ -0,0 +1,51 @@
+package protoprint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/cosmos/cosmos-proto"
+	"github.com/stretchr/testify/require"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestWriteSet(t *testing.T) {
+	b, err := os.ReadFile("../data/osmosis.proto.json")
+	require.NoError(t, err)
+	set := new(descriptorpb.FileDescriptorSet)
+	require.NoError(t, protojson.Unmarshal(b, set))
+
+	dir := t.TempDir()
+	require.NoError(t, WriteSet(dir, set))
+
+	for _, fd := range set.File {
+		_, err := os.Stat(filepath.Join(dir, fd.GetName()))
+		require.NoError(t, err, fd.GetName())
+	}
+
+	query, err := os.ReadFile(filepath.Join(dir, "cosmos/bank/v1beta1/query.proto"))
+	require.NoError(t, err)
+	require.Contains(t, string(query), "syntax = \"proto3\";\n\npackage cosmos.bank.v1beta1;\n")
+	require.Contains(t, string(query), "import \"cosmos/base/query/v1beta1/pagination.proto\";\n")
+	require.Contains(t, string(query), "option go_package = \"github.com/cosmos/cosmos-sdk/x/bank/types\";\n")
+	require.Contains(t, string(query), "  rpc Balance(.cosmos.bank.v1beta1.QueryBalanceRequest) returns (.cosmos.bank.v1beta1.QueryBalanceResponse) {\n")
+	require.Regexp(t, `option \(google.api.http\) = \{ get:\s*"/cosmos/bank/v1beta1/balances/\{address\}/\{denom\}" \};`, string(query))
+	require.Contains(t, string(query), "  repeated .cosmos.base.v1beta1.Coin balances = 1;\n")
+
+	authz, err := os.ReadFile(filepath.Join(dir, "cosmos/authz/v1beta1/authz.proto"))
+	require.NoError(t, err)
+	require.Contains(t, string(authz), `[(cosmos_proto.accepts_interface) = "Authorization"]`)
+}
+
+func TestWriteSet_InvalidPath(t *testing.T) {
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:   proto.String("../escape.proto"),
+		Syntax: proto.String("proto3"),
+	}}}
+
+	require.Error(t, WriteSet(t.TempDir(), set))
+}
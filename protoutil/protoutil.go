@@ -35,3 +35,13 @@ func DynamicMerge(src, dst proto.Message, reset bool) {
 	})
 
 }
+
+// Comments returns the leading comments of the descriptor, which are
+// available only if the file was registered with its source code info.
+func Comments(d protoreflect.Descriptor) string {
+	file := d.ParentFile()
+	if file == nil {
+		return ""
+	}
+	return strings.TrimSpace(file.SourceLocations().ByDescriptor(d).LeadingComments)
+}
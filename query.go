@@ -0,0 +1,70 @@
+package dynamic
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// QueryJSON invokes the query method given its path, in the form /cosmos.bank.v1beta1.Query/AllBalances,
+// cosmos.bank.v1beta1.Query/AllBalances or cosmos.bank.v1beta1.Query.AllBalances, using the protojson
+// encoded request, which can be empty, and returns the protojson encoded response.
+// The method descriptor is resolved through the codec registry.
+func (c *Client) QueryJSON(ctx context.Context, method string, jsonReq []byte, opts ...QueryOption) ([]byte, error) {
+	md, err := c.findMethod(method)
+	if err != nil {
+		return nil, err
+	}
+
+	req := dynamicpb.NewMessage(md.Input())
+	if len(jsonReq) != 0 {
+		err = c.Codec.UnmarshalProtoJSON(jsonReq, req)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s request: %w", md.Input().FullName(), err)
+		}
+	}
+
+	resp := dynamicpb.NewMessage(md.Output())
+	err = c.DynamicQuery(ctx, fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name()), req, resp, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Codec.MarshalProtoJSON(resp)
+}
+
+// findMethod resolves the descriptor of the unary method given its path, see QueryJSON.
+func (c *Client) findMethod(path string) (protoreflect.MethodDescriptor, error) {
+	md, err := c.findMethodDescriptor(path)
+	if err != nil {
+		return nil, err
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("method %s is streaming, see DynamicStream", md.FullName())
+	}
+	return md, nil
+}
+
+// findMethodDescriptor resolves the method descriptor given its path, see QueryJSON.
+func (c *Client) findMethodDescriptor(path string) (protoreflect.MethodDescriptor, error) {
+	service, method, ok := splitMethodPath(path)
+	if !ok {
+		return nil, fmt.Errorf("invalid method path %q", path)
+	}
+
+	desc, err := c.Codec.Registry.FindDescriptorByName(service)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find service %s: %w", service, err)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+	md := sd.Methods().ByName(method)
+	if md == nil {
+		return nil, fmt.Errorf("service %s has no method %s", service, method)
+	}
+	return md, nil
+}
@@ -0,0 +1,43 @@
+package dynamic
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	tendermintv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+	"github.com/fdymylja/dynamic-cosmos/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestClient_QueryJSON(t *testing.T) {
+	conn := testutil.NewConn(t, func(srv *grpc.Server) {
+		tendermintv1beta1.RegisterServiceServer(srv, &legacyNode{})
+	})
+
+	c, err := DialConn(context.Background(), conn, "", WithAppDescriptor(testAppDescriptor()))
+	require.NoError(t, err)
+	defer c.Close()
+
+	resp, err := c.QueryJSON(context.Background(), "cosmos.base.tendermint.v1beta1.Service/GetNodeInfo", nil)
+	require.NoError(t, err)
+	var nodeInfo struct {
+		NodeInfo struct {
+			Network string `json:"network"`
+		} `json:"nodeInfo"`
+	}
+	require.NoError(t, json.Unmarshal(resp, &nodeInfo))
+	require.Equal(t, "legacy-1", nodeInfo.NodeInfo.Network)
+
+	resp, err = c.QueryJSON(context.Background(), "/grpc.health.v1.Health/Check", []byte(`{"service":""}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"status":"SERVING"}`, string(resp))
+
+	_, err = c.QueryJSON(context.Background(), "grpc.health.v1.Health/Check", []byte(`{"unknown":1}`))
+	require.ErrorContains(t, err, "invalid grpc.health.v1.HealthCheckRequest request")
+	_, err = c.QueryJSON(context.Background(), "grpc.health.v1.Health/Missing", nil)
+	require.ErrorContains(t, err, "has no method")
+	_, err = c.QueryJSON(context.Background(), "grpc.health.v1.Health/Watch", nil)
+	require.ErrorContains(t, err, "streaming")
+}
@@ -0,0 +1,76 @@
+package rosetta
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	bankv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/bank/v1beta1"
+	tendermintv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+)
+
+// AccountBalance returns the bank balances of the account at the block, or at the latest block.
+// If currencies are requested only their balances are returned, including zero balances.
+func (s *Server) AccountBalance(ctx context.Context, req *types.AccountBalanceRequest) (*types.AccountBalanceResponse, *types.Error) {
+	height, rosettaErr := s.blockHeight(ctx, req.BlockIdentifier)
+	if rosettaErr != nil {
+		return nil, rosettaErr
+	}
+	// the latest height is fixed, so that the balances and the block identifier match
+	if height == 0 {
+		latest, err := s.node.GetLatestBlock(ctx, &tendermintv1beta1.GetLatestBlockRequest{})
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		height = latest.Block.GetHeader().GetHeight()
+	}
+	block, err := s.node.GetBlockByHeight(ctx, &tendermintv1beta1.GetBlockByHeightRequest{Height: height})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	pages, err := s.client.AtHeight(height).Paginate(ctx, "/cosmos.bank.v1beta1.Query/AllBalances",
+		&bankv1beta1.QueryAllBalancesRequest{Address: req.AccountIdentifier.Address})
+	if err != nil {
+		return nil, wrapError(ErrInternal, err)
+	}
+	defer pages.Close()
+
+	balances := map[string]string{}
+	var denoms []string
+	for pages.Next() {
+		coin := pages.Item().Message()
+		fields := coin.Descriptor().Fields()
+		denom := coin.Get(fields.ByName("denom")).String()
+		balances[denom] = coin.Get(fields.ByName("amount")).String()
+		denoms = append(denoms, denom)
+	}
+	if err = pages.Err(); err != nil {
+		return nil, grpcError(err)
+	}
+
+	if len(req.Currencies) != 0 {
+		denoms = denoms[:0]
+		for _, c := range req.Currencies {
+			denoms = append(denoms, c.Symbol)
+		}
+	}
+	amounts := make([]*types.Amount, len(denoms))
+	for i, denom := range denoms {
+		value, ok := balances[denom]
+		if !ok {
+			value = "0"
+		}
+		amounts[i] = &types.Amount{Value: value, Currency: s.currency(denom)}
+	}
+
+	return &types.AccountBalanceResponse{
+		BlockIdentifier: blockIdentifier(block.BlockId.GetHash(), height),
+		Balances:        amounts,
+	}, nil
+}
+
+// AccountCoins is not supported, as the chain is account based.
+func (s *Server) AccountCoins(context.Context, *types.AccountCoinsRequest) (*types.AccountCoinsResponse, *types.Error) {
+	return nil, wrapError(ErrUnsupported, fmt.Errorf("coins are not supported by account based chains"))
+}
@@ -0,0 +1,279 @@
+package rosetta
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	tendermintv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+	txv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/tx/v1beta1"
+	abci "github.com/cosmos/cosmos-sdk/api/tendermint/abci"
+	tmtypes "github.com/cosmos/cosmos-sdk/api/tendermint/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// coinRegexp matches the coins of the coin_spent and coin_received events amounts, ex: 10uatom.
+var coinRegexp = regexp.MustCompile(`^([0-9]+)([a-zA-Z][a-zA-Z0-9/:._-]{1,127})$`)
+
+// Block returns the block and its transactions, the block is identified by height, or by hash
+// if the Client has a tendermint endpoint, and defaults to the latest block. If both are set,
+// the hash must match the block at the height. The transactions are fetched one by one using
+// GetTx, as it's the only query returning their results, so a block costs a round trip per
+// transaction.
+func (s *Server) Block(ctx context.Context, req *types.BlockRequest) (*types.BlockResponse, *types.Error) {
+	height, rosettaErr := s.blockHeight(ctx, req.BlockIdentifier)
+	if rosettaErr != nil {
+		return nil, rosettaErr
+	}
+
+	var (
+		blockID *tmtypes.BlockID
+		block   *tmtypes.Block
+	)
+	if height == 0 {
+		latest, err := s.node.GetLatestBlock(ctx, &tendermintv1beta1.GetLatestBlockRequest{})
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		blockID, block = latest.BlockId, latest.Block
+	} else {
+		resp, err := s.node.GetBlockByHeight(ctx, &tendermintv1beta1.GetBlockByHeightRequest{Height: height})
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		blockID, block = resp.BlockId, resp.Block
+	}
+
+	header := block.GetHeader()
+	current := blockIdentifier(blockID.GetHash(), header.GetHeight())
+	if id := req.BlockIdentifier; id != nil && id.Hash != nil && !strings.EqualFold(*id.Hash, current.Hash) {
+		return nil, wrapError(ErrNotFound, fmt.Errorf("block %d has hash %s, not %s", current.Index, current.Hash, *id.Hash))
+	}
+	parent := blockIdentifier(header.GetLastBlockId().GetHash(), header.GetHeight()-1)
+	// the parent of the genesis block is the block itself
+	if header.GetHeight() == s.opts.genesisHeight {
+		parent = current
+	}
+
+	txs := make([]*types.Transaction, len(block.GetData().GetTxs()))
+	for i, raw := range block.GetData().GetTxs() {
+		hash := sha256.Sum256(raw)
+		tx, _, rosettaErr := s.transaction(ctx, fmt.Sprintf("%X", hash[:]))
+		if rosettaErr != nil {
+			return nil, rosettaErr
+		}
+		txs[i] = tx
+	}
+
+	return &types.BlockResponse{Block: &types.Block{
+		BlockIdentifier:       current,
+		ParentBlockIdentifier: parent,
+		Timestamp:             header.GetTime().AsTime().UnixMilli(),
+		Transactions:          txs,
+	}}, nil
+}
+
+// BlockTransaction returns the transaction, which must be included in the block.
+func (s *Server) BlockTransaction(ctx context.Context, req *types.BlockTransactionRequest) (*types.BlockTransactionResponse, *types.Error) {
+	tx, height, rosettaErr := s.transaction(ctx, req.TransactionIdentifier.Hash)
+	if rosettaErr != nil {
+		return nil, rosettaErr
+	}
+	if height != req.BlockIdentifier.Index {
+		return nil, wrapError(ErrNotFound, fmt.Errorf("transaction %s was included at height %d", req.TransactionIdentifier.Hash, height))
+	}
+	block, err := s.node.GetBlockByHeight(ctx, &tendermintv1beta1.GetBlockByHeightRequest{Height: height})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if hash := fmt.Sprintf("%X", block.BlockId.GetHash()); !strings.EqualFold(req.BlockIdentifier.Hash, hash) {
+		return nil, wrapError(ErrNotFound, fmt.Errorf("block %d has hash %s, not %s", height, hash, req.BlockIdentifier.Hash))
+	}
+	return &types.BlockTransactionResponse{Transaction: tx}, nil
+}
+
+// blockHeight returns the height of the block identifier, or 0 for the latest block,
+// the hash of identifiers setting both the height and the hash is checked by Block.
+func (s *Server) blockHeight(ctx context.Context, id *types.PartialBlockIdentifier) (int64, *types.Error) {
+	switch {
+	case id == nil || id.Index == nil && id.Hash == nil:
+		return 0, nil
+	case id.Index != nil:
+		return *id.Index, nil
+	}
+
+	hash, err := hex.DecodeString(*id.Hash)
+	if err != nil {
+		return 0, wrapError(ErrInvalid, fmt.Errorf("invalid block hash: %w", err))
+	}
+	tm, err := s.client.Tendermint()
+	if err != nil {
+		return 0, wrapError(ErrUnsupported, err)
+	}
+	block, err := tm.BlockByHash(ctx, hash)
+	if err != nil {
+		return 0, wrapError(ErrUnavailable, err)
+	}
+	if block.Block == nil {
+		return 0, wrapError(ErrNotFound, fmt.Errorf("block %s", *id.Hash))
+	}
+	return block.Block.Height, nil
+}
+
+// transaction fetches the tx and its result, returning the transaction and its height.
+func (s *Server) transaction(ctx context.Context, hash string) (*types.Transaction, int64, *types.Error) {
+	resp, err := s.txs.GetTx(ctx, &txv1beta1.GetTxRequest{Hash: hash})
+	if err != nil {
+		return nil, 0, grpcError(err)
+	}
+	result := resp.GetTxResponse()
+
+	var ops []*types.Operation
+	msgStatus := StatusSuccess
+	if result.GetCode() != 0 {
+		msgStatus = StatusFailure
+	}
+	for _, msg := range resp.GetTx().GetBody().GetMessages() {
+		op, err := s.msgOperation(msg, msgStatus)
+		if err != nil {
+			return nil, 0, wrapError(ErrInternal, err)
+		}
+		op.OperationIdentifier = &types.OperationIdentifier{Index: int64(len(ops))}
+		ops = append(ops, op)
+	}
+	// balance changes, including fees, are reported also for failed txs
+	for _, event := range result.GetEvents() {
+		changes, err := s.balanceOperations(event)
+		if err != nil {
+			return nil, 0, wrapError(ErrInternal, err)
+		}
+		for _, op := range changes {
+			op.OperationIdentifier = &types.OperationIdentifier{Index: int64(len(ops))}
+			ops = append(ops, op)
+		}
+	}
+
+	return &types.Transaction{
+		TransactionIdentifier: &types.TransactionIdentifier{Hash: strings.ToUpper(hash)},
+		Operations:            ops,
+		Metadata: map[string]interface{}{
+			"code":       result.GetCode(),
+			"codespace":  result.GetCodespace(),
+			"gas_wanted": result.GetGasWanted(),
+			"gas_used":   result.GetGasUsed(),
+			"memo":       resp.GetTx().GetBody().GetMemo(),
+		},
+	}, result.GetHeight(), nil
+}
+
+// msgOperation returns the operation of the Msg, decoded using the dynamic codec.
+func (s *Server) msgOperation(msg *anypb.Any, status string) (*types.Operation, error) {
+	typ, err := s.client.Codec.Registry.FindMessageByURL(msg.TypeUrl)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve msg %s: %w", msg.TypeUrl, err)
+	}
+	m := typ.New()
+	if err = s.client.Codec.UnmarshalProto(msg.Value, m.Interface()); err != nil {
+		return nil, fmt.Errorf("unable to decode msg %s: %w", msg.TypeUrl, err)
+	}
+	j, err := s.client.Codec.MarshalProtoJSON(m.Interface())
+	if err != nil {
+		return nil, err
+	}
+	var metadata map[string]interface{}
+	if err = json.Unmarshal(j, &metadata); err != nil {
+		return nil, err
+	}
+
+	op := &types.Operation{
+		Type:     string(m.Descriptor().FullName()),
+		Status:   &status,
+		Metadata: metadata,
+	}
+	if info, ok := s.catalog.Msg(string(m.Descriptor().FullName())); ok && len(info.Signers) != 0 {
+		// the signer is resolved by name, as the catalog descriptor can be a different instance
+		fd := m.Descriptor().Fields().ByName(info.Signers[0].Name())
+		v := m.Get(fd)
+		switch {
+		case fd.IsList() && v.List().Len() != 0:
+			op.Account = &types.AccountIdentifier{Address: v.List().Get(0).String()}
+		case !fd.IsList():
+			op.Account = &types.AccountIdentifier{Address: v.String()}
+		}
+	}
+	return op, nil
+}
+
+// balanceOperations returns the balance changes of coin_spent and coin_received events.
+func (s *Server) balanceOperations(event *abci.Event) ([]*types.Operation, error) {
+	var (
+		opType  string
+		account string
+		sign    string
+	)
+	switch event.Type_ {
+	case OpCoinSpent:
+		opType, sign = OpCoinSpent, "-"
+	case OpCoinReceived:
+		opType = OpCoinReceived
+	default:
+		return nil, nil
+	}
+
+	var ops []*types.Operation
+	for _, attr := range event.Attributes {
+		switch string(attr.Key) {
+		case "spender", "receiver":
+			account = string(attr.Value)
+		case "amount":
+			for _, coin := range strings.Split(string(attr.Value), ",") {
+				if coin == "" {
+					continue
+				}
+				match := coinRegexp.FindStringSubmatch(coin)
+				if match == nil {
+					return nil, fmt.Errorf("invalid coin %q in %s event", coin, event.Type_)
+				}
+				status := StatusSuccess
+				ops = append(ops, &types.Operation{
+					Type:   opType,
+					Status: &status,
+					Amount: &types.Amount{Value: sign + match[1], Currency: s.currency(match[2])},
+				})
+			}
+		}
+	}
+	for _, op := range ops {
+		op.Account = &types.AccountIdentifier{Address: account}
+	}
+	return ops, nil
+}
+
+// currency returns the currency of the denom.
+func (s *Server) currency(denom string) *types.Currency {
+	if c, ok := s.opts.currencies[denom]; ok {
+		return c
+	}
+	return &types.Currency{Symbol: denom}
+}
+
+// grpcError converts gRPC errors to rosetta errors.
+func grpcError(err error) *types.Error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return wrapError(ErrNotFound, err)
+	case codes.InvalidArgument:
+		return wrapError(ErrInvalid, err)
+	case codes.Unimplemented:
+		return wrapError(ErrUnsupported, err)
+	default:
+		return wrapError(ErrUnavailable, err)
+	}
+}
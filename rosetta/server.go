@@ -1 +1,294 @@
+// Package rosetta implements the Rosetta Data API on top of a dynamic.Client.
 package rosetta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	tendermintv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+	txv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/tx/v1beta1"
+	dynamic "github.com/fdymylja/dynamic-cosmos"
+)
+
+const (
+	// StatusSuccess is the status of the operations of successful txs, and of balance changes.
+	StatusSuccess = "success"
+	// StatusFailure is the status of the Msg operations of failed txs.
+	StatusFailure = "failure"
+
+	// OpCoinSpent and OpCoinReceived are the types of the operations
+	// built from the coin_spent and coin_received events.
+	OpCoinSpent    = "coin_spent"
+	OpCoinReceived = "coin_received"
+)
+
+// Errors returned by the Server.
+var (
+	ErrUnavailable = &types.Error{Code: 1, Message: "node is unavailable", Retriable: true}
+	ErrNotFound    = &types.Error{Code: 2, Message: "not found"}
+	ErrUnsupported = &types.Error{Code: 3, Message: "not supported"}
+	ErrInvalid     = &types.Error{Code: 4, Message: "invalid request"}
+	ErrInternal    = &types.Error{Code: 5, Message: "internal error"}
+)
+
+var allErrors = []*types.Error{ErrUnavailable, ErrNotFound, ErrUnsupported, ErrInvalid, ErrInternal}
+
+// wrapError returns a copy of rosettaErr with err as details.
+func wrapError(rosettaErr *types.Error, err error) *types.Error {
+	e := *rosettaErr
+	e.Details = map[string]interface{}{"error": err.Error()}
+	return &e
+}
+
+// Option configures a Server.
+type Option func(o *options)
+
+type options struct {
+	blockchain    string
+	genesisHeight int64
+	currencies    map[string]*types.Currency
+}
+
+// WithBlockchain sets the blockchain of the network identifier, which defaults to the chain-registry
+// name of the chain if the Client was dialed using DialChain, otherwise to the bech32 account prefix.
+func WithBlockchain(blockchain string) Option {
+	return func(o *options) {
+		o.blockchain = blockchain
+	}
+}
+
+// WithGenesisHeight sets the height of the first block of the chain, defaults to 1.
+func WithGenesisHeight(height int64) Option {
+	return func(o *options) {
+		o.genesisHeight = height
+	}
+}
+
+// WithCurrencies sets the currencies of the denoms matching their symbols, ex: to set their decimals.
+// Other denoms are currencies whose symbol is the denom and without decimals.
+func WithCurrencies(currencies ...*types.Currency) Option {
+	return func(o *options) {
+		for _, c := range currencies {
+			o.currencies[c.Symbol] = c
+		}
+	}
+}
+
+// Server implements the Rosetta network, block and account APIs using the gRPC services of the
+// chain. The network identifier is built from the app descriptor, with the chain ID as network.
+//
+// Every Msg of a tx is an operation whose type is the Msg full name, whose account is its first
+// signer and whose metadata is the JSON encoded Msg. As Msgs are opaque, balance changes are
+// operations built from the coin_spent and coin_received events of the tx, including its fees.
+// Balance changes happening outside of txs, such as block rewards, are not reported.
+type Server struct {
+	client  *dynamic.Client
+	catalog *dynamic.Catalog
+	network *types.NetworkIdentifier
+	opts    *options
+	opTypes []string
+	router  http.Handler
+
+	node tendermintv1beta1.ServiceClient
+	txs  txv1beta1.ServiceClient
+
+	// oldestMu guards oldestHeight, the oldest block found on a pruned node.
+	oldestMu     sync.Mutex
+	oldestHeight int64
+}
+
+var (
+	_ server.NetworkAPIServicer = (*Server)(nil)
+	_ server.BlockAPIServicer   = (*Server)(nil)
+	_ server.AccountAPIServicer = (*Server)(nil)
+)
+
+// NewServer creates a Server serving the chain of the client.
+func NewServer(client *dynamic.Client, opts ...Option) (*Server, error) {
+	o := &options{genesisHeight: 1, currencies: map[string]*types.Currency{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.blockchain == "" {
+		o.blockchain = client.App.GetConfiguration().GetBech32AccountAddressPrefix()
+		if client.Chain != nil {
+			o.blockchain = client.Chain.ChainName
+		}
+	}
+
+	catalog, err := client.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		client:  client,
+		catalog: catalog,
+		network: &types.NetworkIdentifier{Blockchain: o.blockchain, Network: client.App.GetChain().GetId()},
+		opts:    o,
+		node:    tendermintv1beta1.NewServiceClient(client.ClientConn()),
+		txs:     txv1beta1.NewServiceClient(client.ClientConn()),
+	}
+	for _, msg := range catalog.Msgs() {
+		s.opTypes = append(s.opTypes, string(msg.Name))
+	}
+	s.opTypes = append(s.opTypes, OpCoinSpent, OpCoinReceived)
+
+	a, err := asserter.NewServer(s.opTypes, true, []*types.NetworkIdentifier{s.network}, nil, false, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create rosetta asserter: %w", err)
+	}
+	s.router = server.NewRouter(
+		server.NewNetworkAPIController(s, a),
+		&blockController{Router: server.NewBlockAPIController(s, a), servicer: s, asserter: a},
+		server.NewAccountAPIController(s, a),
+	)
+	return s, nil
+}
+
+// blockController serves the block API like server.BlockAPIController, except that /block
+// accepts empty block identifiers, which the asserter rejects, to request the latest block.
+type blockController struct {
+	server.Router
+	servicer server.BlockAPIServicer
+	asserter *asserter.Asserter
+}
+
+func (c *blockController) Routes() server.Routes {
+	routes := c.Router.Routes()
+	for i := range routes {
+		if routes[i].Pattern == "/block" {
+			routes[i].HandlerFunc = c.block
+		}
+	}
+	return routes
+}
+
+func (c *blockController) block(w http.ResponseWriter, r *http.Request) {
+	req := new(types.BlockRequest)
+	err := json.NewDecoder(r.Body).Decode(req)
+	if err == nil {
+		if id := req.BlockIdentifier; id == nil || id.Index == nil && id.Hash == nil {
+			err = c.asserter.ValidSupportedNetwork(req.NetworkIdentifier)
+		} else {
+			err = c.asserter.BlockRequest(req)
+		}
+	}
+	if err != nil {
+		server.EncodeJSONResponse(&types.Error{Message: err.Error()}, http.StatusInternalServerError, w)
+		return
+	}
+
+	resp, rosettaErr := c.servicer.Block(r.Context(), req)
+	if rosettaErr != nil {
+		server.EncodeJSONResponse(rosettaErr, http.StatusInternalServerError, w)
+		return
+	}
+	server.EncodeJSONResponse(resp, http.StatusOK, w)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
+// NetworkList returns the network of the chain.
+func (s *Server) NetworkList(context.Context, *types.MetadataRequest) (*types.NetworkListResponse, *types.Error) {
+	return &types.NetworkListResponse{NetworkIdentifiers: []*types.NetworkIdentifier{s.network}}, nil
+}
+
+// NetworkOptions returns the version of the node and the operation types and statuses.
+func (s *Server) NetworkOptions(ctx context.Context, _ *types.NetworkRequest) (*types.NetworkOptionsResponse, *types.Error) {
+	info, err := s.node.GetNodeInfo(ctx, &tendermintv1beta1.GetNodeInfoRequest{})
+	if err != nil {
+		return nil, wrapError(ErrUnavailable, err)
+	}
+	return &types.NetworkOptionsResponse{
+		Version: &types.Version{
+			RosettaVersion: types.RosettaAPIVersion,
+			NodeVersion:    info.GetApplicationVersion().GetVersion(),
+			Metadata: map[string]interface{}{
+				"tendermint_version": info.GetNodeInfo().GetVersion(),
+				"cosmos_sdk_version": info.GetApplicationVersion().GetCosmosSdkVersion(),
+			},
+		},
+		Allow: &types.Allow{
+			OperationStatuses: []*types.OperationStatus{
+				{Status: StatusSuccess, Successful: true},
+				{Status: StatusFailure, Successful: false},
+			},
+			OperationTypes:          s.opTypes,
+			Errors:                  allErrors,
+			HistoricalBalanceLookup: true,
+		},
+	}, nil
+}
+
+// NetworkStatus returns the latest and genesis blocks, and if the node is syncing. If the genesis
+// block was pruned, the oldest block available is reported both as the oldest and genesis block.
+func (s *Server) NetworkStatus(ctx context.Context, _ *types.NetworkRequest) (*types.NetworkStatusResponse, *types.Error) {
+	latest, err := s.node.GetLatestBlock(ctx, &tendermintv1beta1.GetLatestBlockRequest{})
+	if err != nil {
+		return nil, wrapError(ErrUnavailable, err)
+	}
+	var oldest *types.BlockIdentifier
+	genesis, err := s.node.GetBlockByHeight(ctx, &tendermintv1beta1.GetBlockByHeightRequest{Height: s.opts.genesisHeight})
+	if err != nil {
+		genesis, err = s.oldestBlock(ctx, latest.Block.GetHeader().GetHeight())
+		if err != nil {
+			return nil, wrapError(ErrUnavailable, err)
+		}
+		oldest = blockIdentifier(genesis.BlockId.GetHash(), genesis.Block.GetHeader().GetHeight())
+	}
+	syncing, err := s.node.GetSyncing(ctx, &tendermintv1beta1.GetSyncingRequest{})
+	if err != nil {
+		return nil, wrapError(ErrUnavailable, err)
+	}
+
+	current := blockIdentifier(latest.BlockId.GetHash(), latest.Block.GetHeader().GetHeight())
+	synced := !syncing.Syncing
+	return &types.NetworkStatusResponse{
+		CurrentBlockIdentifier: current,
+		CurrentBlockTimestamp:  latest.Block.GetHeader().GetTime().AsTime().UnixMilli(),
+		GenesisBlockIdentifier: blockIdentifier(genesis.BlockId.GetHash(), genesis.Block.GetHeader().GetHeight()),
+		OldestBlockIdentifier:  oldest,
+		SyncStatus:             &types.SyncStatus{CurrentIndex: &current.Index, Synced: &synced},
+		Peers:                  []*types.Peer{},
+	}, nil
+}
+
+// oldestBlock returns the oldest block available on a pruned node, searching it between the
+// last oldest block found and the latest height, as blocks are pruned from the oldest.
+func (s *Server) oldestBlock(ctx context.Context, latest int64) (*tendermintv1beta1.GetBlockByHeightResponse, error) {
+	s.oldestMu.Lock()
+	defer s.oldestMu.Unlock()
+
+	low := s.oldestHeight
+	if low < s.opts.genesisHeight {
+		low = s.opts.genesisHeight
+	}
+	var oldest *tendermintv1beta1.GetBlockByHeightResponse
+	for high := latest; low <= high; {
+		mid := low + (high-low)/2
+		block, err := s.node.GetBlockByHeight(ctx, &tendermintv1beta1.GetBlockByHeightRequest{Height: mid})
+		if err != nil {
+			low = mid + 1
+			continue
+		}
+		oldest, high = block, mid-1
+	}
+	if oldest == nil {
+		return nil, fmt.Errorf("no block available up to height %d", latest)
+	}
+	s.oldestHeight = oldest.Block.GetHeader().GetHeight()
+	return oldest, nil
+}
+
+func blockIdentifier(hash []byte, height int64) *types.BlockIdentifier {
+	return &types.BlockIdentifier{Index: height, Hash: fmt.Sprintf("%X", hash)}
+}
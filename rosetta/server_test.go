@@ -0,0 +1,259 @@
+package rosetta
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	bankv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/bank/v1beta1"
+	abciv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/abci/v1beta1"
+	queryv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/query/v1beta1"
+	reflectionv2alpha1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/reflection/v2alpha1"
+	tendermintv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+	basev1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/v1beta1"
+	txv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/tx/v1beta1"
+	abci "github.com/cosmos/cosmos-sdk/api/tendermint/abci"
+	tmtypes "github.com/cosmos/cosmos-sdk/api/tendermint/types"
+	"github.com/fdymylja/dynamic-cosmos/internal/testutil/testclient"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// rawTx is the only tx of the chain, included at height 2.
+var rawTx = []byte("tx")
+
+// node serves a chain of two blocks, the blocks before oldest are pruned.
+type node struct {
+	tendermintv1beta1.UnimplementedServiceServer
+	oldest int64
+}
+
+func (n node) block(height int64) (*tendermintv1beta1.GetBlockByHeightResponse, error) {
+	if height < 1 || height < n.oldest || height > 2 {
+		return nil, status.Errorf(codes.NotFound, "block %d", height)
+	}
+	block := &tmtypes.Block{
+		Header: &tmtypes.Header{
+			Height:      height,
+			Time:        timestamppb.New(time.Unix(int64(height), 0)),
+			LastBlockId: &tmtypes.BlockID{Hash: []byte{byte(height - 1)}},
+		},
+		Data: &tmtypes.Data{},
+	}
+	if height == 2 {
+		block.Data.Txs = [][]byte{rawTx}
+	}
+	return &tendermintv1beta1.GetBlockByHeightResponse{BlockId: &tmtypes.BlockID{Hash: []byte{byte(height)}}, Block: block}, nil
+}
+
+func (n node) GetBlockByHeight(_ context.Context, req *tendermintv1beta1.GetBlockByHeightRequest) (*tendermintv1beta1.GetBlockByHeightResponse, error) {
+	return n.block(req.Height)
+}
+
+func (n node) GetLatestBlock(context.Context, *tendermintv1beta1.GetLatestBlockRequest) (*tendermintv1beta1.GetLatestBlockResponse, error) {
+	block, err := n.block(2)
+	if err != nil {
+		return nil, err
+	}
+	return &tendermintv1beta1.GetLatestBlockResponse{BlockId: block.BlockId, Block: block.Block}, nil
+}
+
+func (node) GetSyncing(context.Context, *tendermintv1beta1.GetSyncingRequest) (*tendermintv1beta1.GetSyncingResponse, error) {
+	return &tendermintv1beta1.GetSyncingResponse{}, nil
+}
+
+type txs struct {
+	txv1beta1.UnimplementedServiceServer
+}
+
+func (txs) GetTx(_ context.Context, req *txv1beta1.GetTxRequest) (*txv1beta1.GetTxResponse, error) {
+	hash := sha256.Sum256(rawTx)
+	if req.Hash != fmt.Sprintf("%X", hash[:]) {
+		return nil, status.Errorf(codes.NotFound, "tx %s", req.Hash)
+	}
+	msg, err := anypb.New(&bankv1beta1.MsgSend{
+		FromAddress: "from",
+		ToAddress:   "to",
+		Amount:      []*basev1beta1.Coin{{Denom: "stake", Amount: "10"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &txv1beta1.GetTxResponse{
+		Tx: &txv1beta1.Tx{Body: &txv1beta1.TxBody{Messages: []*anypb.Any{msg}, Memo: "memo"}},
+		TxResponse: &abciv1beta1.TxResponse{
+			Height: 2,
+			Txhash: req.Hash,
+			Events: []*abci.Event{
+				{Type_: "coin_spent", Attributes: []*abci.EventAttribute{
+					{Key: []byte("spender"), Value: []byte("from")},
+					{Key: []byte("amount"), Value: []byte("10stake,1fee")},
+				}},
+				{Type_: "coin_received", Attributes: []*abci.EventAttribute{
+					{Key: []byte("receiver"), Value: []byte("to")},
+					{Key: []byte("amount"), Value: []byte("10stake")},
+				}},
+				{Type_: "message", Attributes: []*abci.EventAttribute{
+					{Key: []byte("action"), Value: []byte("send")},
+				}},
+			},
+		},
+	}, nil
+}
+
+type bank struct {
+	bankv1beta1.UnimplementedQueryServer
+}
+
+func (bank) AllBalances(ctx context.Context, req *bankv1beta1.QueryAllBalancesRequest) (*bankv1beta1.QueryAllBalancesResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	height := md.Get("x-cosmos-block-height")
+	if len(height) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "missing height")
+	}
+	return &bankv1beta1.QueryAllBalancesResponse{
+		Balances:   []*basev1beta1.Coin{{Denom: "stake", Amount: height[0] + "00"}},
+		Pagination: &queryv1beta1.PageResponse{},
+	}, nil
+}
+
+func newTestServer(t *testing.T, n *node) *Server {
+	c := testclient.Dial(t, &reflectionv2alpha1.AppDescriptor{
+		Chain:         &reflectionv2alpha1.ChainDescriptor{Id: "test-1"},
+		Configuration: &reflectionv2alpha1.ConfigurationDescriptor{Bech32AccountAddressPrefix: "cosmos"},
+		QueryServices: &reflectionv2alpha1.QueryServicesDescriptor{},
+		Tx: &reflectionv2alpha1.TxDescriptor{Msgs: []*reflectionv2alpha1.MsgDescriptor{
+			{MsgTypeUrl: "/cosmos.bank.v1beta1.MsgSend"},
+		}},
+	}, func(srv *grpc.Server) {
+		tendermintv1beta1.RegisterServiceServer(srv, n)
+		txv1beta1.RegisterServiceServer(srv, &txs{})
+		bankv1beta1.RegisterQueryServer(srv, &bank{})
+	})
+
+	s, err := NewServer(c, WithCurrencies(&types.Currency{Symbol: "stake", Decimals: 6}))
+	require.NoError(t, err)
+	return s
+}
+
+func post(t *testing.T, s *Server, path string, req, resp interface{}) int {
+	b, err := json.Marshal(req)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b)))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp), w.Body.String())
+	return w.Code
+}
+
+func TestServer(t *testing.T) {
+	s := newTestServer(t, &node{})
+	network := &types.NetworkIdentifier{Blockchain: "cosmos", Network: "test-1"}
+
+	list := new(types.NetworkListResponse)
+	require.Equal(t, http.StatusOK, post(t, s, "/network/list", &types.MetadataRequest{}, list))
+	require.Equal(t, []*types.NetworkIdentifier{network}, list.NetworkIdentifiers)
+
+	netStatus := new(types.NetworkStatusResponse)
+	require.Equal(t, http.StatusOK, post(t, s, "/network/status", &types.NetworkRequest{NetworkIdentifier: network}, netStatus))
+	require.Equal(t, &types.BlockIdentifier{Index: 2, Hash: "02"}, netStatus.CurrentBlockIdentifier)
+	require.Equal(t, &types.BlockIdentifier{Index: 1, Hash: "01"}, netStatus.GenesisBlockIdentifier)
+	require.Nil(t, netStatus.OldestBlockIdentifier)
+	require.Equal(t, int64(2000), netStatus.CurrentBlockTimestamp)
+
+	// unknown networks are rejected
+	rosettaErr := new(types.Error)
+	other := &types.NetworkIdentifier{Blockchain: "cosmos", Network: "other-1"}
+	require.Equal(t, http.StatusInternalServerError, post(t, s, "/network/status", &types.NetworkRequest{NetworkIdentifier: other}, rosettaErr))
+
+	// genesis block
+	index := int64(1)
+	block := new(types.BlockResponse)
+	require.Equal(t, http.StatusOK, post(t, s, "/block", &types.BlockRequest{NetworkIdentifier: network, BlockIdentifier: &types.PartialBlockIdentifier{Index: &index}}, block))
+	require.Equal(t, block.Block.BlockIdentifier, block.Block.ParentBlockIdentifier)
+	require.Empty(t, block.Block.Transactions)
+
+	// Msgs and balance changes are translated to operations
+	index = 2
+	block = new(types.BlockResponse)
+	require.Equal(t, http.StatusOK, post(t, s, "/block", &types.BlockRequest{NetworkIdentifier: network, BlockIdentifier: &types.PartialBlockIdentifier{Index: &index}}, block))
+	require.Equal(t, &types.BlockIdentifier{Index: 1, Hash: "01"}, block.Block.ParentBlockIdentifier)
+	require.Len(t, block.Block.Transactions, 1)
+	ops := block.Block.Transactions[0].Operations
+	require.Len(t, ops, 4)
+	require.Equal(t, "cosmos.bank.v1beta1.MsgSend", ops[0].Type)
+	require.Equal(t, StatusSuccess, *ops[0].Status)
+	require.Equal(t, "from", ops[0].Account.Address)
+	require.Equal(t, "to", ops[0].Metadata["toAddress"])
+	require.Equal(t, OpCoinSpent, ops[1].Type)
+	require.Equal(t, &types.Amount{Value: "-10", Currency: &types.Currency{Symbol: "stake", Decimals: 6}}, ops[1].Amount)
+	require.Equal(t, "from", ops[2].Account.Address)
+	require.Equal(t, "-1", ops[2].Amount.Value)
+	require.Equal(t, OpCoinReceived, ops[3].Type)
+	require.Equal(t, "10", ops[3].Amount.Value)
+	require.Equal(t, int64(3), ops[3].OperationIdentifier.Index)
+
+	// an empty block identifier requests the latest block
+	latest := new(types.BlockResponse)
+	require.Equal(t, http.StatusOK, post(t, s, "/block", &types.BlockRequest{NetworkIdentifier: network, BlockIdentifier: &types.PartialBlockIdentifier{}}, latest))
+	require.Equal(t, block, latest)
+
+	tx := new(types.BlockTransactionResponse)
+	require.Equal(t, http.StatusOK, post(t, s, "/block/transaction", &types.BlockTransactionRequest{
+		NetworkIdentifier:     network,
+		BlockIdentifier:       block.Block.BlockIdentifier,
+		TransactionIdentifier: block.Block.Transactions[0].TransactionIdentifier,
+	}, tx))
+	require.Equal(t, block.Block.Transactions[0], tx.Transaction)
+
+	// the block hash must match the height
+	wrongHash := "01"
+	require.Equal(t, http.StatusInternalServerError, post(t, s, "/block", &types.BlockRequest{
+		NetworkIdentifier: network,
+		BlockIdentifier:   &types.PartialBlockIdentifier{Index: &index, Hash: &wrongHash},
+	}, rosettaErr))
+	require.Equal(t, ErrNotFound.Code, rosettaErr.Code)
+	require.Equal(t, http.StatusInternalServerError, post(t, s, "/block/transaction", &types.BlockTransactionRequest{
+		NetworkIdentifier:     network,
+		BlockIdentifier:       &types.BlockIdentifier{Index: 2, Hash: wrongHash},
+		TransactionIdentifier: block.Block.Transactions[0].TransactionIdentifier,
+	}, rosettaErr))
+	require.Equal(t, ErrNotFound.Code, rosettaErr.Code)
+
+	// historical balances
+	index = 1
+	balance := new(types.AccountBalanceResponse)
+	require.Equal(t, http.StatusOK, post(t, s, "/account/balance", &types.AccountBalanceRequest{
+		NetworkIdentifier: network,
+		AccountIdentifier: &types.AccountIdentifier{Address: "from"},
+		BlockIdentifier:   &types.PartialBlockIdentifier{Index: &index},
+		Currencies:        []*types.Currency{{Symbol: "stake", Decimals: 6}, {Symbol: "atom"}},
+	}, balance))
+	require.Equal(t, &types.BlockIdentifier{Index: 1, Hash: "01"}, balance.BlockIdentifier)
+	require.Equal(t, []*types.Amount{
+		{Value: "100", Currency: &types.Currency{Symbol: "stake", Decimals: 6}},
+		{Value: "0", Currency: &types.Currency{Symbol: "atom"}},
+	}, balance.Balances)
+}
+
+func TestServer_PrunedNode(t *testing.T) {
+	s := newTestServer(t, &node{oldest: 2})
+	network := &types.NetworkIdentifier{Blockchain: "cosmos", Network: "test-1"}
+
+	// the oldest block available is reported in place of the pruned genesis block
+	netStatus := new(types.NetworkStatusResponse)
+	require.Equal(t, http.StatusOK, post(t, s, "/network/status", &types.NetworkRequest{NetworkIdentifier: network}, netStatus))
+	require.Equal(t, &types.BlockIdentifier{Index: 2, Hash: "02"}, netStatus.GenesisBlockIdentifier)
+	require.Equal(t, &types.BlockIdentifier{Index: 2, Hash: "02"}, netStatus.OldestBlockIdentifier)
+}
@@ -0,0 +1,428 @@
+// Package schemadiff compares two chain schema snapshots, as produced by
+// codec.Registry.Save, and reports the differences between them.
+package schemadiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// msgServiceName is the name cosmos-sdk modules use for the service
+// defining the messages which can be included in transactions.
+const msgServiceName protoreflect.Name = "Msg"
+
+// Kind identifies the kind of change.
+type Kind int
+
+const (
+	Added Kind = iota
+	Removed
+	Changed
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Element identifies the type of schema element which changed.
+type Element int
+
+const (
+	ElementMessage Element = iota
+	ElementField
+	ElementEnum
+	ElementEnumValue
+	ElementService
+	ElementRPC
+	// ElementMsg identifies a transaction message, which is the
+	// input type of an RPC belonging to a cosmos-sdk Msg service.
+	ElementMsg
+)
+
+func (e Element) String() string {
+	switch e {
+	case ElementMessage:
+		return "message"
+	case ElementField:
+		return "field"
+	case ElementEnum:
+		return "enum"
+	case ElementEnumValue:
+		return "enum value"
+	case ElementService:
+		return "service"
+	case ElementRPC:
+		return "rpc"
+	case ElementMsg:
+		return "msg"
+	default:
+		return fmt.Sprintf("Element(%d)", int(e))
+	}
+}
+
+// Change describes a single difference between two schemas.
+type Change struct {
+	Kind    Kind
+	Element Element
+	// Name is the full name of the element which changed.
+	Name protoreflect.FullName
+	// Breaking reports if the change breaks clients built
+	// against the old schema, either on the wire or in JSON.
+	Breaking bool
+	// Reason is a human-readable description of the change.
+	Reason string
+}
+
+func (c Change) String() string {
+	severity := "non-breaking"
+	if c.Breaking {
+		severity = "breaking"
+	}
+	return fmt.Sprintf("[%s] %s %s %s: %s", severity, c.Element, c.Name, c.Kind, c.Reason)
+}
+
+// Report contains the changes found between two schemas, sorted by name.
+type Report struct {
+	Changes []Change
+}
+
+// Breaking returns only the breaking changes of the Report.
+func (r *Report) Breaking() []Change {
+	var breaking []Change
+	for _, c := range r.Changes {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+	return breaking
+}
+
+// HasBreaking reports if the Report contains at least one breaking change.
+func (r *Report) HasBreaking() bool {
+	return len(r.Breaking()) != 0
+}
+
+func (r *Report) String() string {
+	b := new(strings.Builder)
+	for _, c := range r.Changes {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Diff compares the old and new descriptor sets. Files which import
+// files missing from the set are tolerated, and references to missing
+// types are compared by name only.
+func Diff(old, new *descriptorpb.FileDescriptorSet) (*Report, error) {
+	oldFiles, err := newFiles(old)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build old schema: %w", err)
+	}
+	newFiles, err := newFiles(new)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build new schema: %w", err)
+	}
+
+	return DiffFiles(oldFiles, newFiles), nil
+}
+
+// DiffFiles compares two sets of already resolved files.
+func DiffFiles(old, new *protoregistry.Files) *Report {
+	d := &differ{}
+
+	oldIndex, newIndex := indexFiles(old), indexFiles(new)
+
+	d.diffMessages(oldIndex.messages, newIndex.messages)
+	d.diffEnums(oldIndex.enums, newIndex.enums)
+	d.diffServices(oldIndex.services, newIndex.services)
+	d.diffMsgs(oldIndex.msgs, newIndex.msgs)
+
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		if d.changes[i].Name != d.changes[j].Name {
+			return d.changes[i].Name < d.changes[j].Name
+		}
+		return d.changes[i].Element < d.changes[j].Element
+	})
+
+	return &Report{Changes: d.changes}
+}
+
+func newFiles(set *descriptorpb.FileDescriptorSet) (*protoregistry.Files, error) {
+	if set == nil {
+		return new(protoregistry.Files), nil
+	}
+	return protodesc.FileOptions{AllowUnresolvable: true}.NewFiles(set)
+}
+
+// index groups the top level and nested definitions of a set of files by full name.
+type index struct {
+	messages map[protoreflect.FullName]protoreflect.MessageDescriptor
+	enums    map[protoreflect.FullName]protoreflect.EnumDescriptor
+	services map[protoreflect.FullName]protoreflect.ServiceDescriptor
+	// msgs maps transaction messages to the Msg service RPC which handles them.
+	msgs map[protoreflect.FullName]protoreflect.MethodDescriptor
+}
+
+func indexFiles(files *protoregistry.Files) *index {
+	idx := &index{
+		messages: map[protoreflect.FullName]protoreflect.MessageDescriptor{},
+		enums:    map[protoreflect.FullName]protoreflect.EnumDescriptor{},
+		services: map[protoreflect.FullName]protoreflect.ServiceDescriptor{},
+		msgs:     map[protoreflect.FullName]protoreflect.MethodDescriptor{},
+	}
+
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		idx.addMessages(fd.Messages())
+		idx.addEnums(fd.Enums())
+		for i := 0; i < fd.Services().Len(); i++ {
+			sd := fd.Services().Get(i)
+			idx.services[sd.FullName()] = sd
+			if sd.Name() != msgServiceName {
+				continue
+			}
+			for j := 0; j < sd.Methods().Len(); j++ {
+				md := sd.Methods().Get(j)
+				idx.msgs[md.Input().FullName()] = md
+			}
+		}
+		return true
+	})
+
+	return idx
+}
+
+func (idx *index) addMessages(mds protoreflect.MessageDescriptors) {
+	for i := 0; i < mds.Len(); i++ {
+		md := mds.Get(i)
+		// map entries are compared as part of the field owning them
+		if md.IsMapEntry() {
+			continue
+		}
+		idx.messages[md.FullName()] = md
+		idx.addMessages(md.Messages())
+		idx.addEnums(md.Enums())
+	}
+}
+
+func (idx *index) addEnums(eds protoreflect.EnumDescriptors) {
+	for i := 0; i < eds.Len(); i++ {
+		idx.enums[eds.Get(i).FullName()] = eds.Get(i)
+	}
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(kind Kind, element Element, name protoreflect.FullName, breaking bool, reason string, args ...interface{}) {
+	d.changes = append(d.changes, Change{
+		Kind:     kind,
+		Element:  element,
+		Name:     name,
+		Breaking: breaking,
+		Reason:   fmt.Sprintf(reason, args...),
+	})
+}
+
+func (d *differ) diffMessages(old, new map[protoreflect.FullName]protoreflect.MessageDescriptor) {
+	for name, oldMd := range old {
+		newMd, exists := new[name]
+		if !exists {
+			d.add(Removed, ElementMessage, name, true, "message no longer exists")
+			continue
+		}
+		d.diffFields(oldMd, newMd)
+	}
+	for name := range new {
+		if _, exists := old[name]; !exists {
+			d.add(Added, ElementMessage, name, false, "new message")
+		}
+	}
+}
+
+// diffFields compares fields by number, as that is
+// what identifies a field on the wire.
+func (d *differ) diffFields(old, new protoreflect.MessageDescriptor) {
+	oldFields, newFields := old.Fields(), new.Fields()
+	for i := 0; i < oldFields.Len(); i++ {
+		oldFd := oldFields.Get(i)
+		newFd := newFields.ByNumber(oldFd.Number())
+		if newFd == nil {
+			if old.ReservedNames().Has(oldFd.Name()) || new.ReservedNames().Has(oldFd.Name()) {
+				d.add(Removed, ElementField, oldFd.FullName(), false, "field number %d removed and reserved", oldFd.Number())
+				continue
+			}
+			d.add(Removed, ElementField, oldFd.FullName(), true, "field number %d no longer exists", oldFd.Number())
+			continue
+		}
+		d.diffField(oldFd, newFd)
+	}
+	for i := 0; i < newFields.Len(); i++ {
+		newFd := newFields.Get(i)
+		if oldFields.ByNumber(newFd.Number()) == nil {
+			d.add(Added, ElementField, newFd.FullName(), false, "new field with number %d", newFd.Number())
+		}
+	}
+}
+
+func (d *differ) diffField(old, new protoreflect.FieldDescriptor) {
+	if old.Name() != new.Name() {
+		d.add(Changed, ElementField, old.FullName(), true, "field number %d renamed from %s to %s", old.Number(), old.Name(), new.Name())
+	}
+	if old.JSONName() != new.JSONName() {
+		d.add(Changed, ElementField, old.FullName(), true, "json name changed from %s to %s", old.JSONName(), new.JSONName())
+	}
+	if oldType, newType := fieldTypeName(old), fieldTypeName(new); oldType != newType {
+		d.add(Changed, ElementField, old.FullName(), true, "type changed from %s to %s", oldType, newType)
+	}
+	if old.Cardinality() != new.Cardinality() {
+		d.add(Changed, ElementField, old.FullName(), true, "cardinality changed from %s to %s", old.Cardinality(), new.Cardinality())
+	}
+	if oldOneof, newOneof := oneofName(old), oneofName(new); oldOneof != newOneof {
+		d.add(Changed, ElementField, old.FullName(), true, "oneof changed from %q to %q", oldOneof, newOneof)
+	}
+}
+
+func (d *differ) diffEnums(old, new map[protoreflect.FullName]protoreflect.EnumDescriptor) {
+	for name, oldEd := range old {
+		newEd, exists := new[name]
+		if !exists {
+			d.add(Removed, ElementEnum, name, true, "enum no longer exists")
+			continue
+		}
+		d.diffEnumValues(oldEd, newEd)
+	}
+	for name := range new {
+		if _, exists := old[name]; !exists {
+			d.add(Added, ElementEnum, name, false, "new enum")
+		}
+	}
+}
+
+func (d *differ) diffEnumValues(old, new protoreflect.EnumDescriptor) {
+	oldValues, newValues := old.Values(), new.Values()
+	for i := 0; i < oldValues.Len(); i++ {
+		oldVd := oldValues.Get(i)
+		newVd := newValues.ByName(oldVd.Name())
+		if newVd == nil {
+			d.add(Removed, ElementEnumValue, oldVd.FullName(), true, "enum value %d no longer exists", oldVd.Number())
+			continue
+		}
+		if oldVd.Number() != newVd.Number() {
+			d.add(Changed, ElementEnumValue, oldVd.FullName(), true, "number changed from %d to %d", oldVd.Number(), newVd.Number())
+		}
+	}
+	for i := 0; i < newValues.Len(); i++ {
+		newVd := newValues.Get(i)
+		if oldValues.ByName(newVd.Name()) == nil {
+			d.add(Added, ElementEnumValue, newVd.FullName(), false, "new enum value with number %d", newVd.Number())
+		}
+	}
+}
+
+func (d *differ) diffServices(old, new map[protoreflect.FullName]protoreflect.ServiceDescriptor) {
+	for name, oldSd := range old {
+		newSd, exists := new[name]
+		if !exists {
+			d.add(Removed, ElementService, name, true, "service no longer exists")
+			continue
+		}
+		d.diffMethods(oldSd, newSd)
+	}
+	for name := range new {
+		if _, exists := old[name]; !exists {
+			d.add(Added, ElementService, name, false, "new service")
+		}
+	}
+}
+
+func (d *differ) diffMethods(old, new protoreflect.ServiceDescriptor) {
+	oldMethods, newMethods := old.Methods(), new.Methods()
+	for i := 0; i < oldMethods.Len(); i++ {
+		oldMd := oldMethods.Get(i)
+		newMd := newMethods.ByName(oldMd.Name())
+		if newMd == nil {
+			d.add(Removed, ElementRPC, oldMd.FullName(), true, "rpc no longer exists")
+			continue
+		}
+		if oldMd.Input().FullName() != newMd.Input().FullName() {
+			d.add(Changed, ElementRPC, oldMd.FullName(), true, "request type changed from %s to %s", oldMd.Input().FullName(), newMd.Input().FullName())
+		}
+		if oldMd.Output().FullName() != newMd.Output().FullName() {
+			d.add(Changed, ElementRPC, oldMd.FullName(), true, "response type changed from %s to %s", oldMd.Output().FullName(), newMd.Output().FullName())
+		}
+		if oldMd.IsStreamingClient() != newMd.IsStreamingClient() || oldMd.IsStreamingServer() != newMd.IsStreamingServer() {
+			d.add(Changed, ElementRPC, oldMd.FullName(), true, "streaming changed from %s to %s", streamingType(oldMd), streamingType(newMd))
+		}
+	}
+	for i := 0; i < newMethods.Len(); i++ {
+		newMd := newMethods.Get(i)
+		if oldMethods.ByName(newMd.Name()) == nil {
+			d.add(Added, ElementRPC, newMd.FullName(), false, "new rpc")
+		}
+	}
+}
+
+func (d *differ) diffMsgs(old, new map[protoreflect.FullName]protoreflect.MethodDescriptor) {
+	for name, oldMd := range old {
+		if _, exists := new[name]; !exists {
+			d.add(Removed, ElementMsg, name, true, "msg is no longer handled by %s", oldMd.Parent().FullName())
+		}
+	}
+	for name, newMd := range new {
+		if _, exists := old[name]; !exists {
+			d.add(Added, ElementMsg, name, false, "new msg handled by %s", newMd.Parent().FullName())
+		}
+	}
+}
+
+func fieldTypeName(fd protoreflect.FieldDescriptor) string {
+	switch {
+	case fd.IsMap():
+		return fmt.Sprintf("map<%s, %s>", fieldTypeName(fd.MapKey()), fieldTypeName(fd.MapValue()))
+	case fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind:
+		return string(fd.Message().FullName())
+	case fd.Kind() == protoreflect.EnumKind:
+		return string(fd.Enum().FullName())
+	default:
+		return fd.Kind().String()
+	}
+}
+
+func oneofName(fd protoreflect.FieldDescriptor) protoreflect.Name {
+	// proto3 optional fields are wrapped in synthetic oneofs
+	// which do not change the wire nor the json format.
+	if od := fd.ContainingOneof(); od != nil && !od.IsSynthetic() {
+		return od.Name()
+	}
+	return ""
+}
+
+func streamingType(md protoreflect.MethodDescriptor) string {
+	switch {
+	case md.IsStreamingClient() && md.IsStreamingServer():
+		return "bidirectional"
+	case md.IsStreamingClient():
+		return "client streaming"
+	case md.IsStreamingServer():
+		return "server streaming"
+	default:
+		return "unary"
+	}
+}
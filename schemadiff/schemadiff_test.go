@@ -0,0 +1,109 @@
+package schemadiff
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func loadSet(t *testing.T) *descriptorpb.FileDescriptorSet {
+	b, err := os.ReadFile("../data/osmosis.proto.json")
+	require.NoError(t, err)
+
+	set := new(descriptorpb.FileDescriptorSet)
+	require.NoError(t, protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, set))
+	return set
+}
+
+func findFile(t *testing.T, set *descriptorpb.FileDescriptorSet, path string) *descriptorpb.FileDescriptorProto {
+	for _, fd := range set.File {
+		if fd.GetName() == path {
+			return fd
+		}
+	}
+	t.Fatalf("file not found: %s", path)
+	return nil
+}
+
+func findMessage(t *testing.T, fd *descriptorpb.FileDescriptorProto, name string) *descriptorpb.DescriptorProto {
+	for _, md := range fd.MessageType {
+		if md.GetName() == name {
+			return md
+		}
+	}
+	t.Fatalf("message not found: %s", name)
+	return nil
+}
+
+func findChange(report *Report, element Element, name protoreflect.FullName) (Change, bool) {
+	for _, c := range report.Changes {
+		if c.Element == element && c.Name == name {
+			return c, true
+		}
+	}
+	return Change{}, false
+}
+
+func TestDiff_Equal(t *testing.T) {
+	set := loadSet(t)
+
+	report, err := Diff(set, set)
+	require.NoError(t, err)
+	require.Empty(t, report.Changes)
+}
+
+func TestDiff(t *testing.T) {
+	old := loadSet(t)
+	new := proto.Clone(old).(*descriptorpb.FileDescriptorSet)
+
+	// remove a Msg from the bank Msg service
+	bankTx := findFile(t, new, "cosmos/bank/v1beta1/tx.proto")
+	bankTx.Service[0].Method = bankTx.Service[0].Method[:1]
+	// change a field type and rename another one
+	send := findMessage(t, bankTx, "MsgSend")
+	send.Field[0].Type = descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum()
+	send.Field[1].Name = proto.String("recipient")
+	send.Field[1].JsonName = proto.String("recipient")
+	// add a new message
+	bankTx.MessageType = append(bankTx.MessageType, &descriptorpb.DescriptorProto{Name: proto.String("MsgBurn")})
+
+	report, err := Diff(old, new)
+	require.NoError(t, err)
+	require.True(t, report.HasBreaking())
+	t.Log(report)
+
+	c, found := findChange(report, ElementMsg, "cosmos.bank.v1beta1.MsgMultiSend")
+	require.True(t, found)
+	require.Equal(t, Removed, c.Kind)
+	require.True(t, c.Breaking)
+
+	c, found = findChange(report, ElementRPC, "cosmos.bank.v1beta1.Msg.MultiSend")
+	require.True(t, found)
+	require.Equal(t, Removed, c.Kind)
+
+	c, found = findChange(report, ElementField, "cosmos.bank.v1beta1.MsgSend.from_address")
+	require.True(t, found)
+	require.Equal(t, Changed, c.Kind)
+	require.True(t, c.Breaking)
+
+	_, found = findChange(report, ElementField, "cosmos.bank.v1beta1.MsgSend.to_address")
+	require.True(t, found)
+
+	c, found = findChange(report, ElementMessage, "cosmos.bank.v1beta1.MsgBurn")
+	require.True(t, found)
+	require.Equal(t, Added, c.Kind)
+	require.False(t, c.Breaking)
+
+	// the reverse diff sees the new message as removed
+	reverse, err := Diff(new, old)
+	require.NoError(t, err)
+	c, found = findChange(reverse, ElementMessage, "cosmos.bank.v1beta1.MsgBurn")
+	require.True(t, found)
+	require.Equal(t, Removed, c.Kind)
+	require.True(t, c.Breaking)
+}
@@ -0,0 +1,17 @@
+package dynamic
+
+import (
+	reflectionv2alpha1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/reflection/v2alpha1"
+)
+
+// testAppDescriptor is a minimal app descriptor, used to skip its fetching.
+func testAppDescriptor() *reflectionv2alpha1.AppDescriptor {
+	return &reflectionv2alpha1.AppDescriptor{
+		Authn:         &reflectionv2alpha1.AuthnDescriptor{},
+		Chain:         &reflectionv2alpha1.ChainDescriptor{Id: "test-1"},
+		Codec:         &reflectionv2alpha1.CodecDescriptor{},
+		Configuration: &reflectionv2alpha1.ConfigurationDescriptor{Bech32AccountAddressPrefix: "cosmos"},
+		QueryServices: &reflectionv2alpha1.QueryServicesDescriptor{},
+		Tx:            &reflectionv2alpha1.TxDescriptor{},
+	}
+}
@@ -0,0 +1,78 @@
+package dynamic
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Stream is a gRPC stream of a dynamically resolved method, created using Client.DynamicStream.
+type Stream struct {
+	stream grpc.ClientStream
+	method protoreflect.MethodDescriptor
+}
+
+// DynamicStream opens a stream of the method, see QueryJSON for the accepted path formats.
+// The method descriptor, resolved through the codec registry, defines if the client and the
+// server stream: for methods which are not client streaming a single request must be sent
+// before closing the send direction.
+func (c *Client) DynamicStream(ctx context.Context, method string) (*Stream, error) {
+	md, err := c.findMethodDescriptor(method)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := &grpc.StreamDesc{
+		StreamName:    string(md.Name()),
+		ServerStreams: md.IsStreamingServer(),
+		ClientStreams: md.IsStreamingClient(),
+	}
+	stream, err := c.grpc.NewStream(ctx, desc, fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name()))
+	if err != nil {
+		return nil, err
+	}
+	return &Stream{stream: stream, method: md}, nil
+}
+
+// Method returns the descriptor of the stream method.
+func (s *Stream) Method() protoreflect.MethodDescriptor {
+	return s.method
+}
+
+// Send sends a request, which must be of the method input type.
+func (s *Stream) Send(req proto.Message) error {
+	if name := req.ProtoReflect().Descriptor().FullName(); name != s.method.Input().FullName() {
+		return fmt.Errorf("invalid request %s for method %s", name, s.method.FullName())
+	}
+	return s.stream.SendMsg(req)
+}
+
+// CloseSend closes the send direction of the stream.
+func (s *Stream) CloseSend() error {
+	return s.stream.CloseSend()
+}
+
+// Recv receives a response of the method output type, returning io.EOF when the stream ends.
+func (s *Stream) Recv() (*dynamicpb.Message, error) {
+	resp := dynamicpb.NewMessage(s.method.Output())
+	err := s.stream.RecvMsg(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Header returns the header metadata sent by the server.
+func (s *Stream) Header() (metadata.MD, error) {
+	return s.stream.Header()
+}
+
+// Trailer returns the trailer metadata sent by the server, available once the stream ended.
+func (s *Stream) Trailer() metadata.MD {
+	return s.stream.Trailer()
+}
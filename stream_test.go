@@ -0,0 +1,44 @@
+package dynamic
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/fdymylja/dynamic-cosmos/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+func TestClient_DynamicStream(t *testing.T) {
+	conn := testutil.NewConn(t, nil)
+
+	c, err := DialConn(context.Background(), conn, "", WithAppDescriptor(testAppDescriptor()))
+	require.NoError(t, err)
+	defer c.Close()
+
+	// server streaming
+	stream, err := c.DynamicStream(context.Background(), "grpc.health.v1.Health/Watch")
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&grpc_health_v1.HealthCheckRequest{}))
+	require.NoError(t, stream.CloseSend())
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.EqualValues(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Get(resp.Descriptor().Fields().ByName("status")).Enum())
+
+	// bidirectional streaming
+	stream, err = c.DynamicStream(context.Background(), "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo")
+	require.NoError(t, err)
+	require.Error(t, stream.Send(&grpc_health_v1.HealthCheckRequest{}))
+	require.NoError(t, stream.Send(&grpc_reflection_v1alpha.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1alpha.ServerReflectionRequest_ListServices{ListServices: "*"},
+	}))
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	services := resp.Get(resp.Descriptor().Fields().ByName("list_services_response")).Message()
+	require.NotZero(t, services.Get(services.Descriptor().Fields().ByName("service")).List().Len())
+	require.NoError(t, stream.CloseSend())
+	_, err = stream.Recv()
+	require.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,116 @@
+package dynamic
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// WithTLS makes the gRPC connections use TLS with the provided configuration,
+// a nil configuration verifies the server using the system roots.
+// Use LoadTLSConfig to set up a custom CA or mutual TLS.
+func WithTLS(config *tls.Config) DialOption {
+	return func(options *options) {
+		if config == nil {
+			config = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		options.transportCreds = credentials.NewTLS(config)
+	}
+}
+
+// WithPerRPCCredentials attaches the credentials to every gRPC request.
+func WithPerRPCCredentials(creds credentials.PerRPCCredentials) DialOption {
+	return func(options *options) {
+		options.grpcOpts = append(options.grpcOpts, grpc.WithPerRPCCredentials(creds))
+	}
+}
+
+// WithHeaders attaches the headers to every gRPC request, for example API keys.
+func WithHeaders(headers map[string]string) DialOption {
+	return WithPerRPCCredentials(headerCredentials{headers: headers, requireTLS: false})
+}
+
+// WithBearerToken sets the authorization header of every gRPC request
+// to the bearer token, it requires TLS to be enabled using WithTLS.
+func WithBearerToken(token string) DialOption {
+	return WithPerRPCCredentials(headerCredentials{
+		headers:    map[string]string{"authorization": "Bearer " + token},
+		requireTLS: true,
+	})
+}
+
+// WithGRPCDialOptions adds options used when dialing the gRPC connections,
+// for example interceptors.
+func WithGRPCDialOptions(opts ...grpc.DialOption) DialOption {
+	return func(options *options) {
+		options.grpcOpts = append(options.grpcOpts, opts...)
+	}
+}
+
+// LoadTLSConfig returns a TLS configuration verifying the server using the
+// PEM encoded CA certificates contained in caFile, or the system roots if
+// caFile is empty. If certFile and keyFile are set the client certificate
+// is presented to the server, enabling mutual TLS.
+func LoadTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA certificates: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid CA certificates found in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	switch {
+	case certFile != "" && keyFile != "":
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	case certFile != "" || keyFile != "":
+		return nil, fmt.Errorf("both client certificate and key must be provided")
+	}
+
+	return config, nil
+}
+
+// grpcDialOptions returns the options used to dial
+// both the query connection and the reflection remote.
+func (o *options) grpcDialOptions() []grpc.DialOption {
+	creds := o.transportCreds
+	if creds == nil {
+		creds = insecure.NewCredentials()
+	}
+
+	opts := make([]grpc.DialOption, 0, len(o.grpcOpts)+1)
+	opts = append(opts, grpc.WithTransportCredentials(creds))
+	return append(opts, o.grpcOpts...)
+}
+
+var _ credentials.PerRPCCredentials = headerCredentials{}
+
+// headerCredentials attaches static headers to requests.
+type headerCredentials struct {
+	headers    map[string]string
+	requireTLS bool
+}
+
+func (h headerCredentials) GetRequestMetadata(_ context.Context, _ ...string) (map[string]string, error) {
+	return h.headers, nil
+}
+
+func (h headerCredentials) RequireTransportSecurity() bool {
+	return h.requireTLS
+}
@@ -0,0 +1,105 @@
+package dynamic
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+// selfSignedCert writes a self-signed certificate for 127.0.0.1 in dir.
+func selfSignedCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestTransportOptions(t *testing.T) {
+	certFile, keyFile := selfSignedCert(t, t.TempDir())
+	serverCert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+
+	received := make(chan metadata.MD, 1)
+	srv := grpc.NewServer(
+		grpc.Creds(credentials.NewServerTLSFromCert(&serverCert)),
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			received <- md
+			return handler(ctx, req)
+		}),
+	)
+	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	tlsConfig, err := LoadTLSConfig(certFile, "", "")
+	require.NoError(t, err)
+
+	opts := newOptions([]string{lis.Addr().String()}, nil)
+	for _, o := range []DialOption{WithTLS(tlsConfig), WithBearerToken("secret"), WithHeaders(map[string]string{"x-api-key": "key"})} {
+		o(opts)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, lis.Addr().String(), opts.grpcDialOptions()...)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	md := <-received
+	require.Equal(t, []string{"Bearer secret"}, md.Get("authorization"))
+	require.Equal(t, []string{"key"}, md.Get("x-api-key"))
+
+	// bearer tokens are not sent over insecure connections
+	insecureOpts := newOptions([]string{lis.Addr().String()}, nil)
+	WithBearerToken("secret")(insecureOpts)
+	_, err = grpc.DialContext(ctx, lis.Addr().String(), insecureOpts.grpcDialOptions()...)
+	require.Error(t, err)
+
+	_, err = LoadTLSConfig("", certFile, "")
+	require.Error(t, err)
+}
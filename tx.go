@@ -174,7 +174,7 @@ func (t *Tx) Sign(ctx context.Context) (*txv1beta1.TxRaw, error) {
 }
 
 func (t *Tx) Broadcast(ctx context.Context, mode txv1beta1.BroadcastMode) (<-chan *BroadcastTx, error) {
-	if t.watcher == nil || t.txSvc == nil {
+	if t.txSvc == nil {
 		return nil, fmt.Errorf("this Tx setup does not support broadcasting")
 	}
 
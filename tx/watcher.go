@@ -6,7 +6,6 @@ import (
 	"github.com/cosmos/cosmos-sdk/api/tendermint/abci"
 	"github.com/hashicorp/go-uuid"
 	"github.com/tendermint/tendermint/abci/types"
-	"log"
 	"sync"
 	"time"
 
@@ -22,13 +21,34 @@ type Response struct {
 	Result *abci.ResponseDeliverTx // readonly
 	Block  int64
 	Index  uint32
+	// VerificationErr is the error returned by the InclusionVerifier, if any.
+	VerificationErr error
 }
 
+// InclusionVerifier verifies that the tx of the Response was included in its block.
+type InclusionVerifier func(ctx context.Context, resp *Response) error
+
+// WatcherOption configures a Watcher.
+type WatcherOption func(w *Watcher)
+
+// WithInclusionVerifier verifies the inclusion of the watched txs before sending their Response,
+// setting Response.VerificationErr when the verification fails.
+func WithInclusionVerifier(verify InclusionVerifier) WatcherOption {
+	return func(w *Watcher) {
+		w.verify = verify
+	}
+}
+
+// inclusionVerificationTimeout is the timeout of the InclusionVerifier calls.
+const inclusionVerificationTimeout = 30 * time.Second
+
 type Watcher struct {
 	id string
 
 	doneOnce *sync.Once
 	done     chan struct{}
+	stopped  chan struct{}
+	stopErr  error
 
 	subs   map[string][]chan *Response
 	addSub chan struct {
@@ -36,9 +56,17 @@ type Watcher struct {
 		hash string
 	}
 
+	switchSub chan subscription
+	verify    InclusionVerifier
+
 	client tmrpc.EventsClient // used to stop the subscription
 }
 
+type subscription struct {
+	client tmrpc.EventsClient
+	txs    <-chan coretypes.ResultEvent
+}
+
 // Watch returns a channel that sends a Response, once its found.
 // Contract: *Response is readonly.
 func (w *Watcher) Watch(ctx context.Context, hash string) (<-chan *Response, error) {
@@ -56,6 +84,30 @@ func (w *Watcher) Watch(ctx context.Context, hash string) (<-chan *Response, err
 	}
 }
 
+// Switch moves the subscription of the watcher to another tendermint client,
+// keeping the pending watches. Txs included while switching might be missed.
+func (w *Watcher) Switch(ctx context.Context, client tmrpc.EventsClient) error {
+	txs, err := client.Subscribe(ctx, w.id, newTxQuery)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case w.switchSub <- subscription{client: client, txs: txs}:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-w.done:
+		err = fmt.Errorf("tx: watcher is closed")
+	}
+
+	// the subscription was not handed to the loop, ctx might be done already
+	unsubCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_ = client.Unsubscribe(unsubCtx, w.id, newTxQuery)
+	return err
+}
+
 func (w *Watcher) loop(txs <-chan coretypes.ResultEvent) {
 	for {
 		select {
@@ -64,10 +116,25 @@ func (w *Watcher) loop(txs <-chan coretypes.ResultEvent) {
 			defer cancel()
 			err := w.client.Unsubscribe(ctx, w.id, newTxQuery)
 			if err != nil {
-				log.Printf("unable to close tendermint ws correctly: %s", err)
+				w.stopErr = fmt.Errorf("tx: unable to unsubscribe from tendermint: %w", err)
+			}
+			// pending watches are closed without a response
+			for hash, watchers := range w.subs {
+				for _, watcher := range watchers {
+					close(watcher)
+				}
+				delete(w.subs, hash)
 			}
-			// TODO(fdymylja): close all channels?
+			close(w.stopped)
 			return
+		case sub := <-w.switchSub:
+			go func(old tmrpc.EventsClient) {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				_ = old.Unsubscribe(ctx, w.id, newTxQuery)
+			}(w.client)
+			w.client = sub.client
+			txs = sub.txs
 		case c := <-w.addSub:
 			w.subs[c.hash] = append(w.subs[c.hash], c.c)
 		case newTx := <-txs:
@@ -89,9 +156,11 @@ func (w *Watcher) loop(txs <-chan coretypes.ResultEvent) {
 				Index:  txData.Index,
 			}
 
-			for _, watcher := range watchers {
-				watcher <- resp
-				close(watcher)
+			// verification happens outside the loop, which is not blocked by it
+			if w.verify != nil {
+				go w.verifyAndSend(resp, watchers)
+			} else {
+				send(resp, watchers)
 			}
 
 			delete(w.subs, txHash[0])
@@ -99,6 +168,20 @@ func (w *Watcher) loop(txs <-chan coretypes.ResultEvent) {
 	}
 }
 
+func (w *Watcher) verifyAndSend(resp *Response, watchers []chan *Response) {
+	ctx, cancel := context.WithTimeout(context.Background(), inclusionVerificationTimeout)
+	defer cancel()
+	resp.VerificationErr = w.verify(ctx, resp)
+	send(resp, watchers)
+}
+
+func send(resp *Response, watchers []chan *Response) {
+	for _, watcher := range watchers {
+		watcher <- resp
+		close(watcher)
+	}
+}
+
 func resultProtov1toProtov2(result types.ResponseDeliverTx) *abci.ResponseDeliverTx {
 	// deep copy events
 	events := make([]*abci.Event, len(result.Events))
@@ -130,12 +213,17 @@ func resultProtov1toProtov2(result types.ResponseDeliverTx) *abci.ResponseDelive
 	}
 }
 
-func (w *Watcher) Stop() {
+// Stop unsubscribes from tendermint and closes the channels of the pending
+// watches without a response. It can be called multiple times.
+func (w *Watcher) Stop() error {
 	w.doneOnce.Do(func() {
 		close(w.done)
 	})
+	<-w.stopped
+	return w.stopErr
 }
-func DialWatcher(ctx context.Context, sub tmrpc.EventsClient) (*Watcher, error) {
+
+func DialWatcher(ctx context.Context, sub tmrpc.EventsClient, opts ...WatcherOption) (*Watcher, error) {
 	id, err := uuid.GenerateUUID()
 	if err != nil {
 		return nil, err
@@ -150,12 +238,17 @@ func DialWatcher(ctx context.Context, sub tmrpc.EventsClient) (*Watcher, error)
 		id:       id,
 		doneOnce: new(sync.Once),
 		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
 		subs:     map[string][]chan *Response{},
 		addSub: make(chan struct {
 			c    chan *Response
 			hash string
 		}),
-		client: sub,
+		switchSub: make(chan subscription),
+		client:    sub,
+	}
+	for _, opt := range opts {
+		opt(txWatcher)
 	}
 
 	go txWatcher.loop(ws)
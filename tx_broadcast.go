@@ -3,6 +3,8 @@ package dynamic
 import (
 	"context"
 	"fmt"
+	"time"
+
 	abciv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/abci/v1beta1"
 	txv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/tx/v1beta1"
 	"github.com/cosmos/cosmos-sdk/api/tendermint/abci"
@@ -10,10 +12,16 @@ import (
 	"github.com/tendermint/tendermint/crypto"
 )
 
+// txPollInterval is the interval between tx inclusion
+// checks when no tendermint endpoint is available.
+const txPollInterval = time.Second
+
 // BroadcastTx is an alias of tx.Response and
 // identifies a transaction which was broadcast
 type BroadcastTx = tx.Response
 
+// NewBroadcastTx broadcasts the tx bytes. In sync mode the inclusion of the tx is
+// reported using the watcher or, if it's nil, by polling the node until ctx is done.
 func NewBroadcastTx(ctx context.Context, bytes []byte, mode txv1beta1.BroadcastMode, txSvc txv1beta1.ServiceClient, watcher *tx.Watcher) (<-chan *BroadcastTx, error) {
 	switch mode {
 	case txv1beta1.BroadcastMode_BROADCAST_MODE_BLOCK:
@@ -30,25 +38,21 @@ func NewBroadcastTx(ctx context.Context, bytes []byte, mode txv1beta1.BroadcastM
 		}
 
 		c := make(chan *BroadcastTx, 1)
-		c <- &BroadcastTx{
-			Bytes: bytes,
-			Result: &abci.ResponseDeliverTx{
-				Code:      resp.TxResponse.Code,
-				Data:      []byte(resp.TxResponse.Data),
-				Log:       resp.TxResponse.RawLog,
-				Info:      resp.TxResponse.Info,
-				GasWanted: resp.TxResponse.GasWanted,
-				GasUsed:   resp.TxResponse.GasUsed,
-				Events:    resp.TxResponse.Events,
-				Codespace: resp.TxResponse.Codespace,
-			},
-			Block: resp.TxResponse.Height,
-			Index: 0, // TODO(this is unfilled)
-		}
+		c <- newBroadcastTxFromResponse(bytes, resp.TxResponse)
 		return c, nil
 	case txv1beta1.BroadcastMode_BROADCAST_MODE_SYNC:
 		hash := fmt.Sprintf("%X", crypto.Sha256(bytes))
-		c, err := watcher.Watch(ctx, hash)
+		var (
+			c   <-chan *BroadcastTx
+			err error
+		)
+		// without a watcher we poll the node for the tx inclusion
+		if watcher != nil {
+			c, err = watcher.Watch(ctx, hash)
+			if err != nil {
+				return nil, err
+			}
+		}
 		// this will return only checktx response
 		resp, err := txSvc.BroadcastTx(ctx, &txv1beta1.BroadcastTxRequest{
 			TxBytes: bytes,
@@ -62,6 +66,9 @@ func NewBroadcastTx(ctx context.Context, bytes []byte, mode txv1beta1.BroadcastM
 			return nil, newBroadcastError(resp.TxResponse)
 		}
 
+		if c == nil {
+			c = pollTx(ctx, bytes, hash, txSvc)
+		}
 		return c, nil
 
 	default:
@@ -69,6 +76,54 @@ func NewBroadcastTx(ctx context.Context, bytes []byte, mode txv1beta1.BroadcastM
 	}
 }
 
+// pollTx queries the node for the tx until it's included in a block or ctx
+// is done, in which case the returned channel is closed without a response.
+func pollTx(ctx context.Context, bytes []byte, hash string, txSvc txv1beta1.ServiceClient) <-chan *BroadcastTx {
+	c := make(chan *BroadcastTx, 1)
+	go func() {
+		defer close(c)
+
+		ticker := time.NewTicker(txPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			resp, err := txSvc.GetTx(ctx, &txv1beta1.GetTxRequest{Hash: hash})
+			// not found errors and transient errors are retried
+			if err != nil || resp.TxResponse == nil || resp.TxResponse.Height == 0 {
+				continue
+			}
+
+			c <- newBroadcastTxFromResponse(bytes, resp.TxResponse)
+			return
+		}
+	}()
+	return c
+}
+
+func newBroadcastTxFromResponse(bytes []byte, resp *abciv1beta1.TxResponse) *BroadcastTx {
+	return &BroadcastTx{
+		Bytes: bytes,
+		Result: &abci.ResponseDeliverTx{
+			Code:      resp.Code,
+			Data:      []byte(resp.Data),
+			Log:       resp.RawLog,
+			Info:      resp.Info,
+			GasWanted: resp.GasWanted,
+			GasUsed:   resp.GasUsed,
+			Events:    resp.Events,
+			Codespace: resp.Codespace,
+		},
+		Block: resp.Height,
+		Index: 0, // TODO(this is unfilled)
+	}
+}
+
 func newBroadcastError(resp *abciv1beta1.TxResponse) *BroadcastTxError {
 	return &BroadcastTxError{Response: resp}
 }
@@ -0,0 +1,55 @@
+package dynamic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	abciv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/base/abci/v1beta1"
+	txv1beta1 "github.com/cosmos/cosmos-sdk/api/cosmos/tx/v1beta1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// pollingTxService broadcasts successfully and finds the tx after the first GetTx.
+type pollingTxService struct {
+	txv1beta1.ServiceClient
+	calls int
+}
+
+func (p *pollingTxService) BroadcastTx(_ context.Context, _ *txv1beta1.BroadcastTxRequest, _ ...grpc.CallOption) (*txv1beta1.BroadcastTxResponse, error) {
+	return &txv1beta1.BroadcastTxResponse{TxResponse: &abciv1beta1.TxResponse{}}, nil
+}
+
+func (p *pollingTxService) GetTx(_ context.Context, req *txv1beta1.GetTxRequest, _ ...grpc.CallOption) (*txv1beta1.GetTxResponse, error) {
+	p.calls++
+	if p.calls == 1 {
+		return nil, status.Errorf(codes.NotFound, "tx not found: %s", req.Hash)
+	}
+	return &txv1beta1.GetTxResponse{TxResponse: &abciv1beta1.TxResponse{Txhash: req.Hash, Height: 10, GasUsed: 100}}, nil
+}
+
+func TestNewBroadcastTx_Polling(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	svc := &pollingTxService{}
+	c, err := NewBroadcastTx(ctx, []byte("tx"), txv1beta1.BroadcastMode_BROADCAST_MODE_SYNC, svc, nil)
+	require.NoError(t, err)
+
+	resp, ok := <-c
+	require.True(t, ok)
+	require.Equal(t, int64(10), resp.Block)
+	require.Equal(t, int64(100), resp.Result.GasUsed)
+	require.Equal(t, 2, svc.calls)
+
+	// channel is closed without response when ctx is done
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	c, err = NewBroadcastTx(cancelled, []byte("tx"), txv1beta1.BroadcastMode_BROADCAST_MODE_SYNC, &pollingTxService{}, nil)
+	require.NoError(t, err)
+	_, ok = <-c
+	require.False(t, ok)
+}